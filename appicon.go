@@ -26,20 +26,33 @@ func (s *SVGWalker) AddAppIconSVG(path string) error {
 	if err := s.readAppIconSet(); err != nil {
 		return err
 	}
-	if p, err := s.parseSVG(s.Catalog.AppIcon, path, 13); err != nil || !p.update {
+	p, err := s.parseSVG(s.Catalog.AppIcon.Dir, s.Catalog.AppIcon.Images, path, 13)
+	if err != nil {
+		return err
+	}
+	if !p.update {
+		s.reportAppIcon(path, false)
+		return nil
+	}
+	cfg, err := s.rootSettings()
+	if err != nil {
 		return err
 	}
 	name := filepath.Base(path)
-	target := s.sanitized(name[0 : len(name)-4])
+	target := cfg.sanitized(name[0 : len(name)-4])
+	var nameErr error
 	makeImage := func(idiom string, scale int, size float32) Image {
-		file := fmt.Sprintf("%s-%s-@%d-%d.png", target, idiom, scale, int(size))
+		file, err := executeName(cfg.appIconFile, NameData{Name: target, Scale: scale, Idiom: idiom, Size: size})
+		if err != nil && nameErr == nil {
+			nameErr = err
+		}
 		sizeStr := strings.TrimSuffix(fmt.Sprintf("%.1f", size), ".0")
 		return Image{
 			Scale:     fmt.Sprintf("%dx", scale),
 			Size:      fmt.Sprintf("%sx%s", sizeStr, sizeStr),
 			FileName:  file,
 			Idiom:     idiom,
-			generator: s.pngGenerator(s.Catalog.AppIcon, scale, size, size, path, file),
+			generator: s.pngGenerator(s.Catalog.AppIcon, s.postOptions(nil, path), scale, size, size, path, file),
 		}
 	}
 	s.Catalog.AppIcon.Images = []Image{
@@ -61,6 +74,14 @@ func (s *SVGWalker) AddAppIconSVG(path string) error {
 		makeImage("ipad", 2, 76),
 		makeImage("ipad", 2, 83.5),
 	}
+	s.reportAppIcon(path, true)
+	return nameErr
+}
 
-	return nil
+func (s *SVGWalker) reportAppIcon(path string, update bool) {
+	files := make([]string, len(s.Catalog.AppIcon.Images))
+	for i, image := range s.Catalog.AppIcon.Images {
+		files[i] = image.FileName
+	}
+	s.report.source(path, s.Catalog.AppIcon.Dir, files, update)
 }
@@ -0,0 +1,280 @@
+package asset
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RenderCache stores rendered PNGs by key so identical renders can be shared
+// across catalogs and machines.
+type RenderCache interface {
+	// Get copies the PNG stored under key to path, returning false if there is
+	// none.
+	Get(key, path string) (bool, error)
+	// Put stores the PNG at path under key.
+	Put(key, path string) error
+}
+
+// VersionedConverter is implemented by converters that can report the
+// version of the renderer they use. The version is part of every cache key
+// so upgrading a renderer invalidates its cached output.
+type VersionedConverter interface {
+	Version() string
+}
+
+func converterID(c SVGConverter) string {
+	id := fmt.Sprintf("%T", c)
+	if v, ok := c.(VersionedConverter); ok {
+		id += "@" + v.Version()
+	}
+	return id
+}
+
+// renderKey identifies a render by the SVG contents, converter, output size
+// and post-processing.
+func renderKey(c SVGConverter, svgFile string, scale int, height, width float32, post postOptions) (string, error) {
+	data, err := ioutil.ReadFile(svgFile)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	svgHash := sha256.Sum256(data)
+	fmt.Fprintf(h, "svg=%x\nconverter=%s\nscale=%d\nsize=%gx%g\npost=%+v\n",
+		svgHash, converterID(c), scale, width, height, post)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+var toolVersions struct {
+	sync.Mutex
+	versions map[string]string
+}
+
+// toolVersion returns the first line printed by running binary --version.
+func toolVersion(binary string) string {
+	toolVersions.Lock()
+	defer toolVersions.Unlock()
+	if v, ok := toolVersions.versions[binary]; ok {
+		return v
+	}
+	out, err := exec.Command(binary, "--version").Output()
+	v := "unknown"
+	if err == nil {
+		v = strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	}
+	if toolVersions.versions == nil {
+		toolVersions.versions = map[string]string{}
+	}
+	toolVersions.versions[binary] = v
+	return v
+}
+
+func (RSVGConverter) Version() string  { return toolVersion("rsvg-convert") }
+func (ResvgConverter) Version() string { return toolVersion("resvg") }
+func (i InkScapeConverter) Version() string {
+	if !i.known() {
+		return toolVersion("inkscape")
+	}
+	return fmt.Sprintf("%d", i.Major)
+}
+func (NativeConverter) Version() string     { return "oksvg" }
+func (*PhantomJSConverter) Version() string { return toolVersion("phantomjs") }
+
+// DirCache is a RenderCache in a local or shared directory. When MaxBytes is
+// positive Evict removes the least recently used entries to keep the
+// directory below it. The directory may grow past MaxBytes until then.
+type DirCache struct {
+	Dir      string
+	MaxBytes int64
+
+	mu sync.Mutex
+}
+
+func (d *DirCache) path(key string) (string, error) {
+	if len(key) < 2 || strings.ContainsAny(key, `/\`) {
+		return "", errors.Errorf("%q: invalid cache key", key)
+	}
+	return filepath.Join(d.Dir, key[:2], key+".png"), nil
+}
+
+func (d *DirCache) Get(key, path string) (bool, error) {
+	src, err := d.path(key)
+	if err != nil {
+		return false, err
+	}
+	if err := copyFile(src, path); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	now := time.Now()
+	return true, os.Chtimes(src, now, now)
+}
+
+func (d *DirCache) Put(key, path string) error {
+	dst, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	// Copy to a temporary name first so concurrent readers never see a
+	// partial file.
+	tmp := fmt.Sprintf("%s.%d.tmp", dst, os.Getpid())
+	if err := copyFile(path, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Evict removes the least recently used entries until the cache is below
+// MaxBytes. It reads the whole directory, so call it once after a run
+// rather than after every Put.
+func (d *DirCache) Evict() error {
+	if d.MaxBytes <= 0 {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	type entry struct {
+		path string
+		info os.FileInfo
+	}
+	var (
+		entries []entry
+		total   int64
+	)
+	err := filepath.Walk(d.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() && filepath.Ext(path) == ".png" {
+			entries = append(entries, entry{path, info})
+			total += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].info.ModTime().Before(entries[j].info.ModTime()) })
+	for _, e := range entries {
+		if total <= d.MaxBytes {
+			break
+		}
+		if err := os.Remove(e.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		total -= e.info.Size()
+	}
+	return nil
+}
+
+// HTTPCache is a RenderCache backed by a simple HTTP server that answers
+// GET and PUT requests for URL/<key>.png, such as a local cache proxy.
+type HTTPCache struct {
+	URL    string
+	Client *http.Client
+}
+
+func (h *HTTPCache) client() *http.Client {
+	if h.Client != nil {
+		return h.Client
+	}
+	return http.DefaultClient
+}
+
+func (h *HTTPCache) url(key string) string {
+	return strings.TrimSuffix(h.URL, "/") + "/" + key + ".png"
+}
+
+func (h *HTTPCache) Get(key, path string) (bool, error) {
+	resp, err := h.client().Get(h.url(key))
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode != http.StatusOK:
+		return false, errors.Errorf("%s: unexpected status %s", h.url(key), resp.Status)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		os.Remove(path)
+		return false, err
+	}
+	return true, f.Close()
+}
+
+func (h *HTTPCache) Put(key, path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, h.url(key), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "image/png")
+	resp, err := h.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("%s: unexpected status %s", h.url(key), resp.Status)
+	}
+	return nil
+}
+
+// cachedRender produces file from s.Cache when it holds key, otherwise it
+// calls render and stores the result. Cache failures are logged and treated
+// as misses so a broken cache never fails a build.
+func (s *SVGWalker) cachedRender(key, file string, render func() error) error {
+	if s.Cache == nil || key == "" {
+		return render()
+	}
+	if ok, err := s.Cache.Get(key, file); err != nil {
+		s.report.warn(file, fmt.Sprintf("cache read failed: %v", err))
+	} else if ok {
+		Log("Cached", file)
+		s.report.rendered(file, OutputCached, 0)
+		return nil
+	}
+	if err := render(); err != nil {
+		return err
+	}
+	if err := s.Cache.Put(key, file); err != nil {
+		s.report.warn(file, fmt.Sprintf("cache write failed: %v", err))
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package asset
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirCache(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "cache-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	cache := &DirCache{Dir: filepath.Join(tmpDir, "cache"), MaxBytes: 25}
+	src := filepath.Join(tmpDir, "src.png")
+	out := filepath.Join(tmpDir, "out.png")
+
+	ok, err := cache.Get("aa01", out)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	for _, key := range []string{"", "a", "../escape"} {
+		_, err = cache.Get(key, out)
+		require.Error(t, err, key)
+		require.Error(t, cache.Put(key, src), key)
+	}
+
+	for i, key := range []string{"aa01", "bb02", "cc03"} {
+		require.NoError(t, ioutil.WriteFile(src, []byte(strings.Repeat(key[:1], 10)), 0600))
+		require.NoError(t, cache.Put(key, src))
+		// Make modification times distinct so eviction order is stable.
+		old := time.Now().Add(time.Duration(i-10) * time.Minute)
+		path, err := cache.path(key)
+		require.NoError(t, err)
+		require.NoError(t, os.Chtimes(path, old, old))
+	}
+	ok, err = cache.Get("aa01", out)
+	require.NoError(t, err)
+	require.True(t, ok, "entries are only evicted by Evict")
+	path, err := cache.path("aa01")
+	require.NoError(t, err)
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(path, old, old))
+
+	require.NoError(t, cache.Evict())
+	ok, err = cache.Get("aa01", out)
+	require.NoError(t, err)
+	require.False(t, ok, "oldest entry should have been evicted")
+
+	ok, err = cache.Get("bb02", out)
+	require.NoError(t, err)
+	require.True(t, ok)
+	data, err := ioutil.ReadFile(out)
+	require.NoError(t, err)
+	require.Equal(t, "bbbbbbbbbb", string(data))
+}
+
+type memoryCacheServer struct {
+	sync.Mutex
+	entries map[string][]byte
+}
+
+func (m *memoryCacheServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.Lock()
+	defer m.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		data, ok := m.entries[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	case http.MethodPut:
+		data, _ := ioutil.ReadAll(r.Body)
+		m.entries[r.URL.Path] = data
+	}
+}
+
+func TestSVGWalker_Cache(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "cache-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	server := httptest.NewServer(&memoryCacheServer{entries: map[string][]byte{}})
+	defer server.Close()
+
+	for _, cache := range []RenderCache{
+		&DirCache{Dir: filepath.Join(tmpDir, "cache")},
+		&HTTPCache{URL: server.URL + "/renders/"},
+	} {
+		render := func(dir string) int {
+			catalog := newTestCatalog(t, filepath.Join(tmpDir, dir))
+			conv := &recordingConverter{}
+			walker := &SVGWalker{Converter: conv, Catalog: catalog, Cache: cache}
+			require.NoError(t, walker.Walk("testdata/data"))
+			require.NoError(t, catalog.Write())
+			return len(conv.calls)
+		}
+		require.Equal(t, 9, render("first"))
+		require.Equal(t, 0, render("second"))
+		data, err := ioutil.ReadFile(filepath.Join(tmpDir, "second/Test.xcassets/lock.imageset/lock-2x.png"))
+		require.NoError(t, err)
+		require.Equal(t, "png", string(data))
+		require.NoError(t, os.RemoveAll(filepath.Join(tmpDir, "first")))
+		require.NoError(t, os.RemoveAll(filepath.Join(tmpDir, "second")))
+	}
+}
+
+func TestRenderKey(t *testing.T) {
+	svg := "testdata/data/lock.svg"
+	base, err := renderKey(NativeConverter{}, svg, 2, 10, 10, postOptions{})
+	require.NoError(t, err)
+	for _, other := range []func() (string, error){
+		func() (string, error) { return renderKey(RSVGConverter{}, svg, 2, 10, 10, postOptions{}) },
+		func() (string, error) {
+			return renderKey(NativeConverter{}, "testdata/data/info.svg", 2, 10, 10, postOptions{})
+		},
+		func() (string, error) { return renderKey(NativeConverter{}, svg, 3, 10, 10, postOptions{}) },
+		func() (string, error) { return renderKey(NativeConverter{}, svg, 2, 10, 12, postOptions{}) },
+		func() (string, error) {
+			return renderKey(NativeConverter{}, svg, 2, 10, 10, postOptions{optimize: true})
+		},
+	} {
+		key, err := other()
+		require.NoError(t, err)
+		require.NotEqual(t, base, key)
+	}
+	again, err := renderKey(NativeConverter{}, svg, 2, 10, 10, postOptions{})
+	require.NoError(t, err)
+	require.Equal(t, base, again)
+}
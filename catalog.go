@@ -35,9 +35,10 @@ func writeContents(dir string, v interface{}) error {
 }
 
 type Catalog struct {
-	*Container `json:"-"`
-	AppIcon    *ImageSet   `json:"-"`
-	Info       CatalogInfo `json:"info"`
+	*Container   `json:"-"`
+	AppIcon      *ImageSet   `json:"-"`
+	MessagesIcon *ImageSet   `json:"-"`
+	Info         CatalogInfo `json:"info"`
 }
 
 func (c *Catalog) Write() error {
@@ -47,6 +48,9 @@ func (c *Catalog) Write() error {
 	if err := c.AppIcon.Write(); err != nil {
 		return err
 	}
+	if err := c.MessagesIcon.Write(); err != nil {
+		return err
+	}
 	return c.write()
 }
 
@@ -85,11 +89,16 @@ type ResourceTags struct {
 	OnDemandResourceTags []string `json:"on-demand-resource-tags,omitempty"`
 }
 
+type ImageSetProperties struct {
+	ResourceTags
+	Localizable bool `json:"localizable,omitempty"`
+}
+
 type ImageSet struct {
-	Dir        string      `json:"-"`
-	Info       CatalogInfo `json:"info"`
-	Properties ResourceTags
-	Images     []Image `json:"images"`
+	Dir        string             `json:"-"`
+	Info       CatalogInfo        `json:"info"`
+	Properties ImageSetProperties `json:"properties"`
+	Images     []Image            `json:"images"`
 }
 
 func NewImageSet(path string) (*ImageSet, error) {
@@ -113,45 +122,375 @@ func (i *ImageSet) Write() error {
 	if err := os.MkdirAll(i.Dir, 0700); err != nil {
 		return err
 	}
-	for j, image := range i.Images {
-		if image.generator == nil {
+	if err := generate(i.Images); err != nil {
+		return err
+	}
+	return writeContents(i.Dir, i)
+}
+
+// generate runs the pending generators of images.
+func generate(images []Image) error {
+	return runGenerators(len(images), func(j int) *func() error { return &images[j].generator })
+}
+
+// runGenerators runs and clears the n pending generators returned by gen,
+// stopping at the first error.
+func runGenerators(n int, gen func(j int) *func() error) error {
+	for j := 0; j < n; j++ {
+		generator := *gen(j)
+		if generator == nil {
 			continue
 		}
-		i.Images[j].generator = nil
-		if err := image.generator(); err != nil {
+		*gen(j) = nil
+		if err := generator(); err != nil {
 			return err
 		}
 	}
-	return writeContents(i.Dir, i)
+	return nil
+}
+
+type LaunchImage struct {
+	Dir    string      `json:"-"`
+	Info   CatalogInfo `json:"info"`
+	Images []Image     `json:"images"`
+}
+
+func NewLaunchImage(path string) (*LaunchImage, error) {
+	l := &LaunchImage{Dir: path}
+	exists, err := readContents(l.Dir, l)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		l.Info = defaultCatalogInfo
+	}
+	return l, nil
+}
+
+func (l *LaunchImage) Write() error {
+	if err := os.MkdirAll(l.Dir, 0700); err != nil {
+		return err
+	}
+	if err := generate(l.Images); err != nil {
+		return err
+	}
+	return writeContents(l.Dir, l)
 }
 
 type Image struct {
-	FileName           string                 `json:"filename"`
-	Size               string                 `json:"size,omitempty"`
-	GraphicsFeatureSet string                 `json:"graphics-feature-set,omitempty"`
-	Idiom              string                 `json:"idiom,omitempty"`
-	Memory             string                 `json:"memory,omitempty"`
-	Scale              string                 `json:"scale,omitempty"`
-	Subtype            string                 `json:"subtype,omitempty"`
-	ScreenWidth        string                 `json:"screen-width,omitempty"`
-	WidthClass         string                 `json:"width-class,omitempty"`
-	HeightClass        string                 `json:"height-class,omitempty"`
-	Unassigned         bool                   `json:"unassigned,omitempty"`
-	AlignmentInsets    map[string]interface{} `json:"alignment-insets,omitempty"`
-	generator          func() error
+	FileName             string    `json:"filename"`
+	Size                 string    `json:"size,omitempty"`
+	GraphicsFeatureSet   string    `json:"graphics-feature-set,omitempty"`
+	Idiom                string    `json:"idiom,omitempty"`
+	Platform             string    `json:"platform,omitempty"`
+	Memory               string    `json:"memory,omitempty"`
+	Scale                string    `json:"scale,omitempty"`
+	Subtype              string    `json:"subtype,omitempty"`
+	ScreenWidth          string    `json:"screen-width,omitempty"`
+	WidthClass           string    `json:"width-class,omitempty"`
+	HeightClass          string    `json:"height-class,omitempty"`
+	Unassigned           bool      `json:"unassigned,omitempty"`
+	Locale               string    `json:"locale,omitempty"`
+	LanguageDirection    string    `json:"language-direction,omitempty"`
+	Orientation          string    `json:"orientation,omitempty"`
+	Extent               string    `json:"extent,omitempty"`
+	MinimumSystemVersion string    `json:"minimum-system-version,omitempty"`
+	Resizing             *Resizing `json:"resizing,omitempty"`
+	AlignmentInsets      *Insets   `json:"alignment-insets,omitempty"`
+	generator            func() error
+}
+
+type DataSet struct {
+	Dir        string       `json:"-"`
+	Info       CatalogInfo  `json:"info"`
+	Properties ResourceTags `json:"properties"`
+	Data       []Data       `json:"data"`
+}
+
+func NewDataSet(path string) (*DataSet, error) {
+	d := &DataSet{Dir: path}
+	exists, err := readContents(d.Dir, d)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		d.Info = defaultCatalogInfo
+	}
+	return d, nil
+}
+
+func (d *DataSet) Write() error {
+	if err := os.MkdirAll(d.Dir, 0700); err != nil {
+		return err
+	}
+	if err := runGenerators(len(d.Data), func(j int) *func() error { return &d.Data[j].generator }); err != nil {
+		return err
+	}
+	return writeContents(d.Dir, d)
+}
+
+type Data struct {
+	FileName                string `json:"filename"`
+	Idiom                   string `json:"idiom,omitempty"`
+	UniversalTypeIdentifier string `json:"universal-type-identifier,omitempty"`
+	Memory                  string `json:"memory,omitempty"`
+	GraphicsFeatureSet      string `json:"graphics-feature-set,omitempty"`
+	generator               func() error
+}
+
+type SymbolSet struct {
+	Dir        string       `json:"-"`
+	Info       CatalogInfo  `json:"info"`
+	Properties ResourceTags `json:"properties"`
+	Symbols    []Symbol     `json:"symbols"`
+}
+
+func NewSymbolSet(path string) (*SymbolSet, error) {
+	set := &SymbolSet{Dir: path}
+	exists, err := readContents(set.Dir, set)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		set.Info = defaultCatalogInfo
+	}
+	return set, nil
+}
+
+func (s *SymbolSet) Write() error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return err
+	}
+	if err := runGenerators(len(s.Symbols), func(j int) *func() error { return &s.Symbols[j].generator }); err != nil {
+		return err
+	}
+	return writeContents(s.Dir, s)
+}
+
+type Symbol struct {
+	FileName  string `json:"filename"`
+	Idiom     string `json:"idiom,omitempty"`
+	generator func() error
+}
+
+type StickerPack struct {
+	Dir        string                `json:"-"`
+	Info       CatalogInfo           `json:"info"`
+	Properties StickerPackProperties `json:"properties"`
+	// Stickers lists the sticker folders in the order they appear in
+	// Messages.
+	Stickers []StickerFile `json:"stickers"`
+	// Contents holds the stickers added to the pack by folder name.
+	Contents map[string]*Sticker `json:"-"`
+}
+
+type StickerPackProperties struct {
+	GridSize string `json:"grid-size"`
+}
+
+type StickerFile struct {
+	FileName string `json:"filename"`
+}
+
+func NewStickerPack(path string) (*StickerPack, error) {
+	pack := &StickerPack{Dir: path, Contents: map[string]*Sticker{}}
+	exists, err := readContents(pack.Dir, pack)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		pack.Info = defaultCatalogInfo
+		pack.Properties.GridSize = "regular"
+	}
+	return pack, nil
+}
+
+func (p *StickerPack) Write() error {
+	if err := os.MkdirAll(p.Dir, 0700); err != nil {
+		return err
+	}
+	for n, s := range p.Contents {
+		if err := s.Write(); err != nil {
+			return fmt.Errorf("%s:%v", n, err)
+		}
+	}
+	return writeContents(p.Dir, p)
+}
+
+type Sticker struct {
+	Dir        string            `json:"-"`
+	Info       CatalogInfo       `json:"info"`
+	Properties StickerProperties `json:"properties"`
+	generator  func() error
+}
+
+type StickerProperties struct {
+	FileName           string `json:"filename"`
+	AccessibilityLabel string `json:"accessibility-label,omitempty"`
+}
+
+func NewSticker(path string) (*Sticker, error) {
+	sticker := &Sticker{Dir: path}
+	exists, err := readContents(sticker.Dir, sticker)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		sticker.Info = defaultCatalogInfo
+	}
+	return sticker, nil
+}
+
+func (s *Sticker) Write() error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return err
+	}
+	if err := runGenerators(1, func(int) *func() error { return &s.generator }); err != nil {
+		return err
+	}
+	return writeContents(s.Dir, s)
+}
+
+type ImageStack struct {
+	Dir  string      `json:"-"`
+	Info CatalogInfo `json:"info"`
+	// Layers lists the layer folders from front to back.
+	Layers []ImageStackLayerFile `json:"layers"`
+	// Contents holds the layers added to the stack by folder name.
+	Contents map[string]*ImageStackLayer `json:"-"`
+}
+
+type ImageStackLayerFile struct {
+	FileName string `json:"filename"`
+}
+
+func NewImageStack(path string) (*ImageStack, error) {
+	stack := &ImageStack{Dir: path, Contents: map[string]*ImageStackLayer{}}
+	exists, err := readContents(stack.Dir, stack)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		stack.Info = defaultCatalogInfo
+	}
+	return stack, nil
+}
+
+func (s *ImageStack) Write() error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return err
+	}
+	for n, l := range s.Contents {
+		if err := l.Write(); err != nil {
+			return fmt.Errorf("%s:%v", n, err)
+		}
+	}
+	return writeContents(s.Dir, s)
+}
+
+// ImageStackLayer is a layer of an image stack, holding its images in a
+// Content.imageset.
+type ImageStackLayer struct {
+	Dir     string      `json:"-"`
+	Info    CatalogInfo `json:"info"`
+	Content *ImageSet   `json:"-"`
+}
+
+func NewImageStackLayer(path string) (*ImageStackLayer, error) {
+	layer := &ImageStackLayer{Dir: path}
+	exists, err := readContents(layer.Dir, layer)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		layer.Info = defaultCatalogInfo
+	}
+	if layer.Content, err = NewImageSet(filepath.Join(path, "Content.imageset")); err != nil {
+		return nil, err
+	}
+	return layer, nil
+}
+
+func (l *ImageStackLayer) Write() error {
+	if err := os.MkdirAll(l.Dir, 0700); err != nil {
+		return err
+	}
+	if err := l.Content.Write(); err != nil {
+		return err
+	}
+	return writeContents(l.Dir, l)
+}
+
+// BrandAssets holds the tvOS app icons and top shelf images.
+type BrandAssets struct {
+	Dir    string                 `json:"-"`
+	Info   CatalogInfo            `json:"info"`
+	Assets []BrandAsset           `json:"assets"`
+	Stacks map[string]*ImageStack `json:"-"`
+	Images map[string]*ImageSet   `json:"-"`
+}
+
+type BrandAsset struct {
+	FileName string `json:"filename"`
+	Idiom    string `json:"idiom"`
+	Role     string `json:"role"`
+	Size     string `json:"size"`
+}
+
+func NewBrandAssets(path string) (*BrandAssets, error) {
+	b := &BrandAssets{Dir: path, Stacks: map[string]*ImageStack{}, Images: map[string]*ImageSet{}}
+	exists, err := readContents(b.Dir, b)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		b.Info = defaultCatalogInfo
+	}
+	return b, nil
+}
+
+func (b *BrandAssets) Write() error {
+	if err := os.MkdirAll(b.Dir, 0700); err != nil {
+		return err
+	}
+	for n, s := range b.Stacks {
+		if err := s.Write(); err != nil {
+			return fmt.Errorf("%s:%v", n, err)
+		}
+	}
+	for n, i := range b.Images {
+		if err := i.Write(); err != nil {
+			return fmt.Errorf("%s:%v", n, err)
+		}
+	}
+	return writeContents(b.Dir, b)
 }
 
 type Container struct {
-	Dir    string
-	Groups map[string]*Group
-	Images map[string]*ImageSet
+	Dir          string
+	Groups       map[string]*Group
+	Images       map[string]*ImageSet
+	LaunchImages map[string]*LaunchImage
+	DataSets     map[string]*DataSet
+	SymbolSets   map[string]*SymbolSet
+	StickerPacks map[string]*StickerPack
+	ImageStacks  map[string]*ImageStack
+	BrandAssets  map[string]*BrandAssets
+
+	namespace string
 }
 
 func NewContainer(dir string) *Container {
 	return &Container{
-		Dir:    dir,
-		Groups: map[string]*Group{},
-		Images: map[string]*ImageSet{},
+		Dir:          dir,
+		Groups:       map[string]*Group{},
+		Images:       map[string]*ImageSet{},
+		LaunchImages: map[string]*LaunchImage{},
+		DataSets:     map[string]*DataSet{},
+		SymbolSets:   map[string]*SymbolSet{},
+		StickerPacks: map[string]*StickerPack{},
+		ImageStacks:  map[string]*ImageStack{},
+		BrandAssets:  map[string]*BrandAssets{},
 	}
 }
 
@@ -187,6 +526,42 @@ func (c *Container) write() error {
 			return fmt.Errorf("%s:%v", n, err)
 		}
 	}
+
+	for n, l := range c.LaunchImages {
+		if err := l.Write(); err != nil {
+			return fmt.Errorf("%s:%v", n, err)
+		}
+	}
+
+	for n, d := range c.DataSets {
+		if err := d.Write(); err != nil {
+			return fmt.Errorf("%s:%v", n, err)
+		}
+	}
+
+	for n, s := range c.SymbolSets {
+		if err := s.Write(); err != nil {
+			return fmt.Errorf("%s:%v", n, err)
+		}
+	}
+
+	for n, p := range c.StickerPacks {
+		if err := p.Write(); err != nil {
+			return fmt.Errorf("%s:%v", n, err)
+		}
+	}
+
+	for n, s := range c.ImageStacks {
+		if err := s.Write(); err != nil {
+			return fmt.Errorf("%s:%v", n, err)
+		}
+	}
+
+	for n, b := range c.BrandAssets {
+		if err := b.Write(); err != nil {
+			return fmt.Errorf("%s:%v", n, err)
+		}
+	}
 	return nil
 }
 
@@ -212,3 +587,22 @@ func NewCatalog(dir string) (*Catalog, error) {
 	return c, nil
 }
 
+// CreateCatalog creates an empty catalog at dir, along with any missing
+// parent folders, and writes its Contents.json. It fails if dir already
+// holds a catalog.
+func CreateCatalog(dir string) (*Catalog, error) {
+	if filepath.Ext(dir) != ".xcassets" {
+		return nil, fmt.Errorf("%s: not a catalog folder", dir)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Contents.json")); err == nil {
+		return nil, fmt.Errorf("%s: catalog already exists", dir)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	c, err := NewCatalog(dir)
+	if err != nil {
+		return nil, err
+	}
+	return c, c.Write()
+}
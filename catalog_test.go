@@ -14,6 +14,7 @@ import (
 	"github.com/JamesClonk/vultr/Godeps/_workspace/src/github.com/stretchr/testify/assert"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
+	"github.com/surullabs/asset/golden"
 )
 
 // Test data sourced from https://github.com/encharm/Font-Awesome-SVG-PNG
@@ -26,23 +27,21 @@ func TestConvert(t *testing.T) {
 	require.NoError(t, err)
 	defer os.RemoveAll(tmpDir)
 
-	calls := fakeCallsFromTestData(tmpDir)
 	converter, err := StartPhantomJSConverter()
 	require.NoError(t, err)
 	defer func() { assert.NoError(t, converter.Stop()) }()
-	for _, c := range calls {
-		t.Run(c.src, func(t *testing.T) {
-			require.NoError(t,
-				converter.Convert(c.scale, c.height, c.width, c.svg, c.png),
-				"failed to convert %+v", c.svg)
-			golden, err := ioutil.ReadFile(c.src)
-			require.NoError(t, err)
-			actual, err := ioutil.ReadFile(c.png)
-			require.NoError(t, err)
-			require.Equal(t, golden, actual)
+	var cases []golden.Case
+	for _, c := range fakeCallsFromTestData(tmpDir) {
+		cases = append(cases, golden.Case{
+			Name:   c.src,
+			SVG:    c.svg,
+			Golden: c.src,
+			Scale:  c.scale,
+			Height: c.height,
+			Width:  c.width,
 		})
-		break
 	}
+	golden.Run(t, converter, cases, golden.Options{})
 }
 
 type fakeConvertCall struct {
@@ -168,3 +167,27 @@ func TestSVGWalker_Walk(t *testing.T) {
 	require.Equal(t, len(mock.calls), mock.called)
 
 }
+
+func TestCreateCatalog(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "create-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	dir := filepath.Join(tmpDir, "app", "Test.xcassets")
+	_, err = NewCatalog(dir)
+	require.Error(t, err)
+
+	catalog, err := CreateCatalog(dir)
+	require.NoError(t, err)
+	require.Equal(t, dir, catalog.Dir)
+	data, err := ioutil.ReadFile(filepath.Join(dir, "Contents.json"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"author": "indigo"`)
+	_, err = NewCatalog(dir)
+	require.NoError(t, err)
+
+	_, err = CreateCatalog(dir)
+	require.Error(t, err)
+	_, err = CreateCatalog(filepath.Join(tmpDir, "Test"))
+	require.Error(t, err)
+}
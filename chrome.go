@@ -0,0 +1,381 @@
+package asset
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+var ErrNoChrome = errors.New("chrome not installed. Chrome or Chromium (https://www.chromium.org/) is needed to convert SVG files. Set CHROME_PATH if it is not on the PATH.")
+
+var chromeBinaries = []string{
+	"chromium",
+	"chromium-browser",
+	"google-chrome",
+	"google-chrome-stable",
+	"chrome",
+	"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+	"/Applications/Chromium.app/Contents/MacOS/Chromium",
+}
+
+// FindChrome returns the path to a Chrome or Chromium binary, preferring
+// the CHROME_PATH environment variable.
+func FindChrome() (string, error) {
+	if p := os.Getenv("CHROME_PATH"); p != "" {
+		return exec.LookPath(p)
+	}
+	for _, b := range chromeBinaries {
+		if p, err := exec.LookPath(b); err == nil {
+			return p, nil
+		}
+	}
+	return "", ErrNoChrome
+}
+
+// ChromeConverter renders SVGs with a headless Chrome controlled over the
+// DevTools protocol. One browser is shared by a pool of tabs so Convert may be
+// called concurrently.
+type ChromeConverter struct {
+	renders int64 // accessed atomically; first for 64-bit alignment
+	cmd     *exec.Cmd
+	dir     string
+	conn    *cdpConn
+	tabs    chan string
+	version string
+}
+
+func StartChromeConverter() (*ChromeConverter, error) {
+	tabs := runtime.NumCPU()
+	if tabs > 8 {
+		tabs = 8
+	}
+	return StartChromeConverterTabs(tabs)
+}
+
+func StartChromeConverterTabs(tabs int) (*ChromeConverter, error) {
+	bin, err := FindChrome()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := ioutil.TempDir("", "chrome")
+	if err != nil {
+		return nil, err
+	}
+	args := []string{
+		"--headless",
+		"--disable-gpu",
+		"--hide-scrollbars",
+		"--no-first-run",
+		"--no-default-browser-check",
+		"--remote-debugging-port=0",
+		"--user-data-dir=" + dir,
+	}
+	if os.Geteuid() == 0 {
+		args = append(args, "--no-sandbox")
+	}
+	cmd := exec.Command(bin, append(args, "about:blank")...)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return nil, errors.Wrap(err, "failed to start chrome")
+	}
+	c := &ChromeConverter{cmd: cmd, dir: dir}
+
+	url, err := devToolsURL(stderr, 30*time.Second)
+	if err != nil {
+		c.kill()
+		return nil, err
+	}
+	if c.conn, err = dialCDP(url); err != nil {
+		c.kill()
+		return nil, err
+	}
+	var version struct {
+		Product string `json:"product"`
+	}
+	if err := c.conn.call("", "Browser.getVersion", nil, &version); err != nil {
+		c.kill()
+		return nil, err
+	}
+	c.version = version.Product
+	c.tabs = make(chan string, tabs)
+	for i := 0; i < tabs; i++ {
+		session, err := c.openTab()
+		if err != nil {
+			c.kill()
+			return nil, err
+		}
+		c.tabs <- session
+	}
+	return c, nil
+}
+
+// devToolsURL reads the browser's websocket URL from chrome's stderr.
+func devToolsURL(stderr io.Reader, timeout time.Duration) (string, error) {
+	const prefix = "DevTools listening on "
+	found := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			if line := scanner.Text(); strings.HasPrefix(line, prefix) {
+				found <- strings.TrimSpace(strings.TrimPrefix(line, prefix))
+				break
+			}
+		}
+		close(found)
+		// Keep draining so chrome never blocks on a full pipe.
+		for scanner.Scan() {
+		}
+	}()
+	select {
+	case url, ok := <-found:
+		if !ok {
+			return "", errors.New("chrome exited before the DevTools endpoint was ready")
+		}
+		return url, nil
+	case <-time.After(timeout):
+		return "", errors.New("timed out waiting for the chrome DevTools endpoint")
+	}
+}
+
+func (c *ChromeConverter) openTab() (string, error) {
+	var target struct {
+		TargetID string `json:"targetId"`
+	}
+	if err := c.conn.call("", "Target.createTarget", map[string]interface{}{"url": "about:blank"}, &target); err != nil {
+		return "", err
+	}
+	var attached struct {
+		SessionID string `json:"sessionId"`
+	}
+	params := map[string]interface{}{"targetId": target.TargetID, "flatten": true}
+	if err := c.conn.call("", "Target.attachToTarget", params, &attached); err != nil {
+		return "", err
+	}
+	transparent := map[string]interface{}{"color": map[string]int{"r": 0, "g": 0, "b": 0, "a": 0}}
+	if err := c.conn.call(attached.SessionID, "Emulation.setDefaultBackgroundColorOverride", transparent, nil); err != nil {
+		return "", err
+	}
+	return attached.SessionID, nil
+}
+
+func (c *ChromeConverter) Version() string {
+	return c.version
+}
+
+func (c *ChromeConverter) kill() {
+	if c.conn != nil {
+		c.conn.close()
+	}
+	if c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+		c.cmd.Wait()
+	}
+	os.RemoveAll(c.dir)
+}
+
+func (c *ChromeConverter) Stop() error {
+	defer os.RemoveAll(c.dir)
+	err := c.conn.call("", "Browser.close", nil, nil)
+	c.conn.close()
+	done := make(chan error, 1)
+	go func() { done <- c.cmd.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		c.cmd.Process.Kill()
+		<-done
+	}
+	return err
+}
+
+const chromeHTML = `<!DOCTYPE html>
+<html>
+<head><style>
+	html, body { margin: 0; padding: 0; background: transparent; overflow: hidden; }
+	img { position: absolute; top: 0; left: 0; }
+</style></head>
+<body data-convert="%d"><img src="data:image/svg+xml;base64,%s" width="%v" height="%v"></body>
+</html>`
+
+const chromeWaitJS = `new Promise(function(resolve) {
+	(function check() {
+		var img = document.images[0];
+		if (document.body && document.body.dataset.convert === "%d" && img && img.complete) {
+			resolve(true);
+		} else {
+			setTimeout(check, 5);
+		}
+	})();
+})`
+
+func (c *ChromeConverter) Convert(scale int, height, width float32, svgFile, pngFile string) error {
+	data, err := ioutil.ReadFile(svgFile)
+	if err != nil {
+		return err
+	}
+	session := <-c.tabs
+	defer func() { c.tabs <- session }()
+
+	id := atomic.AddInt64(&c.renders, 1)
+	h, w := pixelSize(scale, height, width)
+	metrics := map[string]interface{}{
+		"width":             int(width + 0.999),
+		"height":            int(height + 0.999),
+		"deviceScaleFactor": scale,
+		"mobile":            false,
+	}
+	if err := c.conn.call(session, "Emulation.setDeviceMetricsOverride", metrics, nil); err != nil {
+		return errors.Wrapf(err, "%s: failed to set viewport", svgFile)
+	}
+	html := fmt.Sprintf(chromeHTML, id, base64.StdEncoding.EncodeToString(data), width, height)
+	nav := map[string]interface{}{"url": "data:text/html;base64," + base64.StdEncoding.EncodeToString([]byte(html))}
+	if err := c.conn.call(session, "Page.navigate", nav, nil); err != nil {
+		return errors.Wrapf(err, "%s: failed to load", svgFile)
+	}
+	wait := map[string]interface{}{"expression": fmt.Sprintf(chromeWaitJS, id), "awaitPromise": true}
+	if err := c.conn.call(session, "Runtime.evaluate", wait, nil); err != nil {
+		return errors.Wrapf(err, "%s: failed to render", svgFile)
+	}
+	var shot struct {
+		Data string `json:"data"`
+	}
+	capture := map[string]interface{}{
+		"format":      "png",
+		"fromSurface": true,
+		"clip": map[string]interface{}{
+			"x": 0, "y": 0, "width": float64(w) / float64(scale), "height": float64(h) / float64(scale), "scale": 1,
+		},
+	}
+	if err := c.conn.call(session, "Page.captureScreenshot", capture, &shot); err != nil {
+		return errors.Wrapf(err, "%s: failed to capture", svgFile)
+	}
+	png, err := base64.StdEncoding.DecodeString(shot.Data)
+	if err != nil {
+		return errors.Wrapf(err, "%s: failed to decode screenshot", svgFile)
+	}
+	if err := ioutil.WriteFile(pngFile, png, 0644); err != nil {
+		return errors.Wrap(err, "failed to write")
+	}
+	return nil
+}
+
+// cdpConn is a minimal DevTools protocol client multiplexing browser and
+// flattened tab sessions over one websocket.
+type cdpConn struct {
+	ws      *websocket.Conn
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]chan cdpResponse
+	err     error
+}
+
+type cdpResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func dialCDP(url string) (*cdpConn, error) {
+	ws, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to chrome")
+	}
+	c := &cdpConn{ws: ws, pending: map[int]chan cdpResponse{}}
+	go c.read()
+	return c, nil
+}
+
+func (c *cdpConn) read() {
+	for {
+		var r cdpResponse
+		if err := c.ws.ReadJSON(&r); err != nil {
+			c.mu.Lock()
+			c.err = err
+			for id, ch := range c.pending {
+				close(ch)
+				delete(c.pending, id)
+			}
+			c.mu.Unlock()
+			return
+		}
+		if r.ID == 0 {
+			// An event; nothing waits on these.
+			continue
+		}
+		c.mu.Lock()
+		ch := c.pending[r.ID]
+		delete(c.pending, r.ID)
+		c.mu.Unlock()
+		if ch != nil {
+			ch <- r
+		}
+	}
+}
+
+func (c *cdpConn) call(session, method string, params, result interface{}) error {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return errors.Wrapf(c.err, "%s: connection closed", method)
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan cdpResponse, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	msg := map[string]interface{}{"id": id, "method": method}
+	if params != nil {
+		msg["params"] = params
+	}
+	if session != "" {
+		msg["sessionId"] = session
+	}
+	c.writeMu.Lock()
+	err := c.ws.WriteJSON(msg)
+	c.writeMu.Unlock()
+	if err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return errors.Wrapf(err, "%s: failed to send", method)
+	}
+	r, ok := <-ch
+	if !ok {
+		return errors.Errorf("%s: connection closed", method)
+	}
+	if r.Error != nil {
+		return errors.Errorf("%s: %s (%d)", method, r.Error.Message, r.Error.Code)
+	}
+	if result != nil {
+		return json.Unmarshal(r.Result, result)
+	}
+	return nil
+}
+
+func (c *cdpConn) close() error {
+	return c.ws.Close()
+}
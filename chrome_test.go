@@ -0,0 +1,59 @@
+package asset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevToolsURL(t *testing.T) {
+	url, err := devToolsURL(strings.NewReader("some warning\nDevTools listening on ws://127.0.0.1:9222/devtools/browser/abc\n"), time.Second)
+	require.NoError(t, err)
+	require.Equal(t, "ws://127.0.0.1:9222/devtools/browser/abc", url)
+
+	_, err = devToolsURL(strings.NewReader("crashed\n"), time.Second)
+	require.Error(t, err)
+}
+
+func TestChromeConverter(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	if _, err := FindChrome(); err != nil {
+		t.Skip(err)
+	}
+	tmpDir, err := ioutil.TempDir("", "chrome-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	converter, err := StartChromeConverterTabs(2)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, converter.Stop()) }()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for scale := 1; scale <= 3; scale++ {
+		wg.Add(1)
+		go func(scale int) {
+			defer wg.Done()
+			out := filepath.Join(tmpDir, strings.Repeat("x", scale)+".png")
+			errs[scale] = converter.Convert(scale, 30, 20, "testdata/data/lock.svg", out)
+		}(scale)
+	}
+	wg.Wait()
+	for scale := 1; scale <= 3; scale++ {
+		require.NoError(t, errs[scale])
+		img, err := decodeImage(filepath.Join(tmpDir, strings.Repeat("x", scale)+".png"))
+		require.NoError(t, err)
+		require.Equal(t, 20*scale, img.Bounds().Dx())
+		require.Equal(t, 30*scale, img.Bounds().Dy())
+		_, _, _, a := img.At(0, 0).RGBA()
+		require.Zero(t, a, "background not transparent")
+	}
+}
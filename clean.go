@@ -0,0 +1,93 @@
+package asset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// generatedExts lists the extensions of the sets written by this package.
+var generatedExts = map[string]bool{
+	".imageset":        true,
+	".appiconset":      true,
+	".launchimage":     true,
+	".dataset":         true,
+	".symbolset":       true,
+	".stickerpack":     true,
+	".stickersiconset": true,
+	".brandassets":     true,
+	".imagestack":      true,
+}
+
+// CleanCatalog removes every image set and other set written by this package
+// from the catalog at dir, along with the groups it wrote that are left
+// empty, and returns the paths removed. Sets and groups are recognized by
+// the author in their Contents.json, so the catalog's Contents.json and
+// anything added in Xcode, such as a hand made app icon, are kept.
+func CleanCatalog(dir string) ([]string, error) {
+	if _, err := NewCatalog(dir); err != nil {
+		return nil, err
+	}
+	removed, _, err := cleanDir(dir)
+	return removed, err
+}
+
+// cleanDir cleans the group or catalog at dir and reports whether nothing
+// but its Contents.json is left.
+func cleanDir(dir string) ([]string, bool, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, false, err
+	}
+	var removed []string
+	empty := true
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		switch ext := filepath.Ext(e.Name()); {
+		case e.IsDir() && generatedExts[ext]:
+			ours, err := generated(path)
+			if err != nil {
+				return removed, false, err
+			}
+			if !ours {
+				empty = false
+				continue
+			}
+		case e.IsDir() && ext == "":
+			r, groupEmpty, err := cleanDir(path)
+			removed = append(removed, r...)
+			if err != nil {
+				return removed, false, err
+			}
+			ours, err := generated(path)
+			if err != nil {
+				return removed, false, err
+			}
+			if !groupEmpty || !ours {
+				empty = false
+				continue
+			}
+		default:
+			if e.Name() != "Contents.json" {
+				empty = false
+			}
+			continue
+		}
+		Log("Removing", path)
+		if err := os.RemoveAll(path); err != nil {
+			return removed, false, err
+		}
+		removed = append(removed, path)
+	}
+	return removed, empty, nil
+}
+
+// generated reports whether the set or group at dir was written by this
+// package.
+func generated(dir string) (bool, error) {
+	var contents struct {
+		Info CatalogInfo `json:"info"`
+	}
+	ok, err := readContents(dir, &contents)
+	return ok && contents.Info.Author == defaultCatalogInfo.Author, err
+}
@@ -0,0 +1,60 @@
+package asset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanCatalog(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "clean-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	catalog := newTestCatalog(t, tmpDir)
+	dir := catalog.Dir
+	require.NoError(t, catalog.Write())
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{
+		"a.svg":          testSVG,
+		"icons/lock.svg": testSVG,
+		"kept/b.svg":     testSVG,
+	})
+	walker := &SVGWalker{Converter: NativeConverter{}, Catalog: catalog}
+	require.NoError(t, walker.Walk(src))
+	stack := filepath.Join(tmpDir, "tv.svg")
+	require.NoError(t, ioutil.WriteFile(stack, []byte(testLayeredSVG), 0600))
+	require.NoError(t, walker.AddImageStackSVG(stack))
+	require.NoError(t, catalog.Write())
+	xcode := `{"info": {"author": "xcode", "version": 1}, "images": []}`
+	writeTree(t, dir, map[string]string{
+		"kept/Brand.colorset/Contents.json": `{"colors": []}`,
+		"Logo.imageset/Contents.json":       xcode,
+		"Logo.imageset/logo.pdf":            "pdf",
+		"Hand/Contents.json":                `{"info": {"author": "xcode", "version": 1}}`,
+	})
+
+	removed, err := CleanCatalog(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(dir, "a.imageset"),
+		filepath.Join(dir, "icons", "lock.imageset"),
+		filepath.Join(dir, "icons"),
+		filepath.Join(dir, "kept", "b.imageset"),
+		filepath.Join(dir, "tv.imagestack"),
+	}, removed)
+	entries, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	for _, kept := range []string{"kept/Brand.colorset", "Logo.imageset/logo.pdf", "Hand"} {
+		_, err = os.Stat(filepath.Join(dir, kept))
+		require.NoError(t, err, kept)
+	}
+
+	_, err = CleanCatalog(tmpDir)
+	require.Error(t, err)
+}
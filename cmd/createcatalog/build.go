@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/surullabs/asset"
+)
+
+// buildOptions are the flags of the commands that walk a source folder.
+type buildOptions struct {
+	out, src, appIcon, sanitizer string
+	converter                    string
+	tagReport                    bool
+	report                       string
+	tags                         tagRules
+	quantize, data, symbols      globs
+	sprites                      globs
+	recolor                      recolorRules
+	cacheDir, cacheURL           string
+	cacheMB                      int64
+	launch                       launchOptions
+	stickers                     stickerOptions
+	tv                           asset.BrandAssetsOptions
+	walker                       *asset.SVGWalker
+}
+
+func buildFlags(fs *flag.FlagSet) *buildOptions {
+	o := &buildOptions{walker: &asset.SVGWalker{}}
+	walker := o.walker
+	fs.StringVar(&o.out, "out", "", "Output directory for the asset catalog")
+	fs.StringVar(&o.src, "src", "", "Source directory, if not given as an argument")
+	fs.StringVar(&o.appIcon, "appicon", "", "Path to the SVG to use as an app icon")
+	fs.StringVar(&o.launch.image, "launchimage", "", "Path to an SVG logo centered on every launch image size")
+	fs.StringVar(&o.launch.background, "launch-background", "", "Path to an SVG logo centered on a storyboard launch background image set")
+	fs.StringVar(&o.launch.color, "launch-color", "#ffffff", "Background color of launch images as #rrggbb")
+	fs.StringVar(&o.stickers.dir, "stickers", "", "Folder of SVGs rendered, in name order, into an iMessage sticker pack")
+	fs.StringVar(&o.stickers.size, "sticker-size", "", "Grid size of the sticker pack (small|regular|large)")
+	fs.StringVar(&o.stickers.icon, "messages-icon", "", "Path to the SVG to use as the iMessage app icon")
+	fs.StringVar(&o.tv.AppIcon, "tv-appicon", "", "Path to a layered SVG split into the tvOS app icon image stacks")
+	fs.StringVar(&o.tv.TopShelf, "top-shelf", "", "Path to the SVG to use as the tvOS top shelf image")
+	fs.StringVar(&o.tv.TopShelfWide, "top-shelf-wide", "", "Path to the SVG to use as the wide tvOS top shelf image")
+	fs.StringVar(&o.converter, "converter", "auto", "SVG converter to use ("+strings.Join(asset.Converters, "|")+")")
+	fs.BoolVar(&walker.ForceUpdate, "force", false, "If true all svgs are updated")
+	fs.BoolVar(&walker.SanitizePaths, "sanitize", false, "If true any spaces in paths are converted into _")
+	fs.StringVar(&o.sanitizer, "sanitizer", "", "Comma separated sanitizers applied to names (spaces, ascii, transliterate, lower, kebab, snake, camel). Implies -sanitize")
+	fs.StringVar(&walker.ImageSetName, "imageset-name", "", "Template for image set names (default "+asset.DefaultImageSetName+")")
+	fs.StringVar(&walker.FileName, "file-name", "", "Template for generated PNG file names (default "+asset.DefaultFileName+")")
+	fs.StringVar(&walker.AppIconFileName, "appicon-file-name", "", "Template for app icon PNG file names (default "+asset.DefaultAppIconFileName+")")
+	fs.BoolVar(&walker.AllowUpscale, "upscale", false, "If true PNG/JPEG sources may be upscaled to missing scales")
+	fs.BoolVar(&walker.MirrorRTL, "mirror-rtl", false, "If true a right-to-left variant is generated for every SVG without one by mirroring it")
+	fs.BoolVar(&walker.Optimize, "optimize", false, "If true generated PNGs are losslessly recompressed and the bytes saved are printed")
+	fs.Var(&o.quantize, "quantize", "Reduce PNGs of sources matching this glob to an 8-bit palette. May be repeated")
+	fs.Float64Var(&walker.QuantizeMaxError, "quantize-max-error", asset.DefaultQuantizeMaxError, "Largest RMS error accepted from -quantize before keeping the original")
+	fs.StringVar(&o.cacheDir, "cache", "", "Directory used to cache rendered PNGs across runs and catalogs")
+	fs.Int64Var(&o.cacheMB, "cache-size", 0, "If positive the -cache directory is trimmed below this many megabytes after each build")
+	fs.StringVar(&o.cacheURL, "cache-url", "", "Base URL of an HTTP render cache accepting GET and PUT")
+	fs.Var(&o.data, "data", "Copy files matching this glob into data sets, e.g. '*.json'. May be repeated")
+	fs.Var(&o.symbols, "symbols", "Turn SVG glyphs matching this glob into custom symbol sets. May be repeated")
+	fs.Var(&o.sprites, "sprites", "Split SVG sprite sheets matching this glob into an image set per <symbol>. May be repeated")
+	fs.Var(&o.recolor, "recolor", "Add a recolored image set <name>-<suffix> for SVGs matching a glob as <glob>=<suffix>:[<from>>]<to>[,...]. <to> alone replaces every color. May be repeated")
+	fs.Var(&o.tags, "odr", "Assign on-demand resource tags as <glob or folder>=<tag>[,<tag>...]. May be repeated")
+	fs.StringVar(&o.report, "report", "", "Write a JSON report of every source and output PNG to this file")
+	fs.BoolVar(&o.tagReport, "odr-report", false, "If true the total bytes of the image, data and symbol sets of each on-demand resource tag are printed")
+	return o
+}
+
+// setup checks the options, taking the source directory from args unless
+// set by -src, and configures the walker.
+func (o *buildOptions) setup(args []string) error {
+	switch {
+	case len(args) > 1:
+		return fmt.Errorf("expected a single source directory, got %s", strings.Join(args, " "))
+	case len(args) == 1:
+		o.src = args[0]
+	case o.src == "":
+		return errors.New("no input directory specified")
+	}
+	if o.out != "" && filepath.Ext(o.out) != ".xcassets" {
+		return fmt.Errorf("unsupported output directory %s (must be end in .xcassets)", o.out)
+	}
+	walker := o.walker
+	var err error
+	if walker.Sanitizer, err = asset.ParseSanitizer(o.sanitizer); err != nil {
+		return err
+	}
+	walker.ResourceTags, walker.Quantize, walker.Data, walker.Symbols = o.tags, o.quantize, o.data, o.symbols
+	walker.Sprites, walker.Recolor = o.sprites, o.recolor
+	switch {
+	case o.cacheDir != "" && o.cacheURL != "":
+		return errors.New("only one of -cache and -cache-url may be set")
+	case o.cacheDir != "":
+		walker.Cache = &asset.DirCache{Dir: o.cacheDir, MaxBytes: o.cacheMB << 20}
+	case o.cacheURL != "":
+		walker.Cache = &asset.HTTPCache{URL: o.cacheURL}
+	}
+	return nil
+}
+
+// watched lists the sources read by gen.
+func (o *buildOptions) watched() []string {
+	watched := []string{o.src}
+	for _, p := range []string{o.appIcon, o.launch.image, o.launch.background, o.stickers.dir, o.stickers.icon, o.tv.AppIcon, o.tv.TopShelf, o.tv.TopShelfWide} {
+		if p != "" {
+			watched = append(watched, p)
+		}
+	}
+	return watched
+}
+
+func buildCommandFlags(fs *flag.FlagSet) func([]string) error {
+	o := buildFlags(fs)
+	return func(args []string) error {
+		if o.out == "" {
+			return errors.New("no output directory specifed")
+		}
+		if err := o.setup(args); err != nil {
+			return err
+		}
+		return o.gen()
+	}
+}
+
+func printTagReport(c *asset.Catalog) error {
+	usage, err := c.ResourceTagUsage()
+	if err != nil {
+		return err
+	}
+	for _, u := range usage {
+		warning := ""
+		if u.OverLimit() {
+			warning = " (exceeds limit)"
+		}
+		fmt.Printf("%s\t%d sets\t%d bytes%s\n", u.Tag, u.Sets, u.Bytes, warning)
+	}
+	return nil
+}
+
+func printSavings(walker *asset.SVGWalker) {
+	savings, total := walker.Savings()
+	if len(savings) == 0 {
+		return
+	}
+	for _, s := range savings {
+		fmt.Printf("%s\t%d files\t%d -> %d bytes\tsaved %d\n", s.ImageSet, s.Files, s.Before, s.After, s.Saved())
+	}
+	fmt.Printf("total\t%d files\t%d -> %d bytes\tsaved %d\n", total.Files, total.Before, total.After, total.Saved())
+}
+
+func writeReport(path string, report *asset.Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+type launchOptions struct {
+	image, background, color string
+}
+
+func addLaunch(walker *asset.SVGWalker, l launchOptions) error {
+	opts := asset.LaunchImageOptions{}
+	if l.color != "" {
+		bg, err := asset.ParseHexColor(l.color)
+		if err != nil {
+			return err
+		}
+		opts.Background = bg
+	}
+	if l.image != "" {
+		if err := walker.AddLaunchImageSVG(l.image, opts); err != nil {
+			return err
+		}
+	}
+	if l.background != "" {
+		return walker.AddLaunchBackgroundSVG(l.background, opts)
+	}
+	return nil
+}
+
+type stickerOptions struct {
+	dir, size, icon string
+}
+
+func addStickers(walker *asset.SVGWalker, s stickerOptions) error {
+	if s.dir != "" {
+		if err := walker.AddStickerDir(s.dir, asset.StickerPackOptions{GridSize: s.size}); err != nil {
+			return err
+		}
+	}
+	if s.icon != "" {
+		return walker.AddMessagesIconSVG(s.icon)
+	}
+	return nil
+}
+
+// add adds the sources to the walker's catalog.
+func (o *buildOptions) add() error {
+	walker := o.walker
+	if err := walker.Walk(o.src); err != nil {
+		return err
+	}
+	if o.appIcon != "" {
+		if err := walker.AddAppIconSVG(o.appIcon); err != nil {
+			return err
+		}
+	}
+	if err := addLaunch(walker, o.launch); err != nil {
+		return err
+	}
+	if err := addStickers(walker, o.stickers); err != nil {
+		return err
+	}
+	if o.tv.AppIcon != "" || o.tv.TopShelf != "" || o.tv.TopShelfWide != "" {
+		return walker.AddBrandAssetsSVG(o.tv)
+	}
+	return nil
+}
+
+// startConverter starts the converter used by build, returning a function
+// that stops it.
+func (o *buildOptions) startConverter() (func(), error) {
+	converter, stop, err := asset.StartConverter(o.converter)
+	if err != nil {
+		return nil, err
+	}
+	o.walker.Converter = converter
+	return func() {
+		if err := stop(); err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: failed to stop converter cleanly: %v\n", err)
+		}
+	}, nil
+}
+
+// gen builds the catalog once with a converter of its own.
+func (o *buildOptions) gen() error {
+	stop, err := o.startConverter()
+	if err != nil {
+		return err
+	}
+	defer stop()
+	return o.build()
+}
+
+// build builds the catalog with the converter started by startConverter.
+func (o *buildOptions) build() error {
+	c, err := asset.NewCatalog(o.out)
+	if err != nil {
+		return err
+	}
+	walker := o.walker
+	walker.Catalog = c
+	if err := o.add(); err != nil {
+		return err
+	}
+	if err := c.Write(); err != nil {
+		return err
+	}
+	if cache, ok := walker.Cache.(*asset.DirCache); ok {
+		if err := cache.Evict(); err != nil {
+			return err
+		}
+	}
+	if o.report != "" {
+		if err := writeReport(o.report, walker.Report()); err != nil {
+			return err
+		}
+	}
+	printSavings(walker)
+	if o.tagReport {
+		return printTagReport(c)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// defaultConfigFile is read, if present, when -config is not given.
+const defaultConfigFile = "createcatalog.yaml"
+
+// loadConfig sets the flags of fs not given on the command line from the
+// YAML file at path, a map of flag names to values. Lists set repeatable
+// flags once per item. Flags of other commands are ignored so that every
+// command can share one file.
+func loadConfig(fs *flag.FlagSet, path string) error {
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigFile
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !explicit {
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "%s: failed to read config", path)
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return errors.Wrapf(err, "%s: failed to parse config", path)
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	known := allFlags()
+	for name, value := range values {
+		if !known[name] || name == "config" {
+			return fmt.Errorf("%s: unknown flag %s", path, name)
+		}
+		if set[name] || fs.Lookup(name) == nil {
+			continue
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			items = []interface{}{value}
+		}
+		for _, item := range items {
+			if err := fs.Set(name, fmt.Sprint(item)); err != nil {
+				return errors.Wrapf(err, "%s: invalid value for %s", path, name)
+			}
+		}
+	}
+	return nil
+}
+
+// allFlags returns the names of the flags of every command.
+func allFlags() map[string]bool {
+	names := map[string]bool{"v": true}
+	for _, c := range commands {
+		fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+		c.flags(fs)
+		fs.VisitAll(func(f *flag.Flag) { names[f.Name] = true })
+	}
+	return names
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "createcatalog-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	config := filepath.Join(tmpDir, "config.yaml")
+	require.NoError(t, ioutil.WriteFile(config, []byte(`
+out: Config.xcassets
+appicon: icon.svg
+force: true
+quantize-max-error: 0.5
+data: ['*.json', '*.txt']
+symbols: ['*.glyph.svg']
+addr: localhost:9000
+`), 0600))
+
+	parse := func(args ...string) *buildOptions {
+		fs := flag.NewFlagSet("build", flag.ContinueOnError)
+		o := buildFlags(fs)
+		require.NoError(t, fs.Parse(args))
+		require.NoError(t, loadConfig(fs, config))
+		return o
+	}
+
+	// Flags of other commands, such as addr, are ignored.
+	o := parse()
+	require.Equal(t, "Config.xcassets", o.out)
+	require.Equal(t, "icon.svg", o.appIcon)
+	require.True(t, o.walker.ForceUpdate)
+	require.Equal(t, 0.5, o.walker.QuantizeMaxError)
+	require.Equal(t, globs{"*.json", "*.txt"}, o.data)
+	require.Equal(t, globs{"*.glyph.svg"}, o.symbols)
+
+	// The command line wins, including for repeatable flags.
+	o = parse("-out", "Flag.xcassets", "-data", "*.plist", "-force=false")
+	require.Equal(t, "Flag.xcassets", o.out)
+	require.Equal(t, globs{"*.plist"}, o.data)
+	require.False(t, o.walker.ForceUpdate)
+	require.Equal(t, "icon.svg", o.appIcon)
+
+	fs := flag.NewFlagSet("build", flag.ContinueOnError)
+	buildFlags(fs)
+	require.Error(t, loadConfig(fs, filepath.Join(tmpDir, "missing.yaml")))
+	for _, bad := range []string{"nope: 1\n", "config: other.yaml\n", "force: maybe\n", "out: [\n"} {
+		require.NoError(t, ioutil.WriteFile(config, []byte(bad), 0600))
+		require.Error(t, loadConfig(fs, config), bad)
+	}
+
+	// The default file is optional.
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(tmpDir))
+	defer os.Chdir(wd)
+	require.NoError(t, loadConfig(fs, ""))
+}
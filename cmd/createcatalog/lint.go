@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/surullabs/asset"
+)
+
+func lintFlags(fs *flag.FlagSet) func([]string) error {
+	o := buildFlags(fs)
+	return func(args []string) error {
+		var problems []string
+		if len(args) > 0 || o.src != "" {
+			var err error
+			if problems, err = o.lintSources(args); err != nil {
+				return err
+			}
+		}
+		if o.out != "" {
+			if _, err := os.Stat(o.out); err == nil {
+				found, err := asset.LintCatalog(o.out)
+				if err != nil {
+					return err
+				}
+				for _, p := range found {
+					problems = append(problems, p.String())
+				}
+			}
+		}
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+		if len(problems) > 0 {
+			return fmt.Errorf("%d problems found", len(problems))
+		}
+		return nil
+	}
+}
+
+// lintSources walks the sources into a throwaway catalog, so that neither
+// -out nor the sprite cache is touched, and returns the error and warnings
+// of the walk.
+func (o *buildOptions) lintSources(args []string) ([]string, error) {
+	if err := o.setup(args); err != nil {
+		return nil, err
+	}
+	tmpDir, err := ioutil.TempDir("", "lint")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	name := "Lint.xcassets"
+	if o.out != "" {
+		name = filepath.Base(o.out)
+	}
+	catalog, err := asset.CreateCatalog(filepath.Join(tmpDir, name))
+	if err != nil {
+		return nil, err
+	}
+	o.walker.Catalog, o.walker.Converter = catalog, asset.NativeConverter{}
+	if o.walker.SpriteDir == "" {
+		o.walker.SpriteDir = filepath.Join(tmpDir, "sprites")
+	}
+	var problems []string
+	if err := o.add(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	return append(problems, o.walker.Report().Warnings...), nil
+}
@@ -1,84 +1,220 @@
 package main
 
 import (
-	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/surullabs/asset"
 )
 
-func validate(out string) error {
-	if out == "" {
-		return errors.New("no output directory specifed")
+// command is a subcommand of createcatalog.
+type command struct {
+	name, args, help string
+	// flags defines the flags of the command on fs and returns the function
+	// run with the remaining arguments once they are parsed.
+	flags func(fs *flag.FlagSet) func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"init", "[<path/to/Catalog.xcassets>]", "Create an empty catalog", initFlags},
+		{"build", "[<src>]", "Add the sources in src to the catalog", buildCommandFlags},
+		{"serve", "[<src>]", "Build the catalog, rebuild it on changes and serve previews of it", serveFlags},
+		{"lint", "[<src>]", "Check the sources in src and the catalog for problems", lintFlags},
+		{"clean", "[<path/to/Catalog.xcassets>]", "Remove the generated sets from the catalog", cleanFlags},
+		{"info", "[<path/to/Catalog.xcassets>]", "Summarize the contents of the catalog", infoFlags},
+		{"preview", "[<path/to/Catalog.xcassets>]", "Write an HTML contact sheet of the catalog", previewFlags},
 	}
-	if len(flag.Args()) != 1 {
-		return errors.New("no input directory specified")
+}
+
+// run parses the common and command flags from args, fills in the flags not
+// given from the config file and runs cmd.
+func run(cmd command, args []string) error {
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	config := fs.String("config", "", "YAML file of flag values (default "+defaultConfigFile+" if present)")
+	verbose := fs.Bool("v", false, "If true verbose output is printed")
+	runCmd := cmd.flags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s [flags] %s\n\n%s.\n\n", filepath.Base(os.Args[0]), cmd.name, cmd.args, cmd.help)
+		fs.PrintDefaults()
 	}
-	if filepath.Ext(out) != ".xcassets" {
-		return fmt.Errorf("unsupported output directory %s (must be end in .xcassets)", out)
+	fs.Parse(args)
+	if err := loadConfig(fs, *config); err != nil {
+		return err
 	}
-	return nil
+	if *verbose {
+		asset.Log = func(args ...interface{}) { fmt.Println(args...) }
+	}
+	return runCmd(fs.Args())
 }
 
-func gen(out, appIcon string, force, sanitize bool) error {
-	c, err := asset.NewCatalog(out)
-	if err != nil {
-		return err
+func usage() {
+	name := filepath.Base(os.Args[0])
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags] [args]\n\nCommands:\n", name)
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", c.name, c.help)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun %s <command> -h for the flags of a command. Without a command, build is run.\n", name)
+}
+
+// catalogArg returns the catalog given as the only argument, or else by -out.
+func catalogArg(out string, args []string) (string, error) {
+	switch {
+	case len(args) > 1:
+		return "", fmt.Errorf("expected a single catalog, got %s", strings.Join(args, " "))
+	case len(args) == 1:
+		out = args[0]
+	case out == "":
+		return "", fmt.Errorf("no catalog specified")
+	}
+	out = filepath.Clean(out)
+	if filepath.Ext(out) != ".xcassets" {
+		return "", fmt.Errorf("unsupported catalog %s (must end in .xcassets)", out)
 	}
-	converter, err := asset.StartPhantomJSConverter()
-	if err != nil {
+	return out, nil
+}
+
+func initFlags(fs *flag.FlagSet) func([]string) error {
+	out := fs.String("out", "", "Catalog to create")
+	return func(args []string) error {
+		dir, err := catalogArg(*out, args)
+		if err != nil {
+			return err
+		}
+		_, err = asset.CreateCatalog(dir)
 		return err
 	}
-	defer func() {
-		if err := converter.Stop(); err != nil {
-			fmt.Fprintln(os.Stderr, "WARNING: failed to stop phantomjs cleanly: %v", err)
+}
+
+func cleanFlags(fs *flag.FlagSet) func([]string) error {
+	out := fs.String("out", "", "Catalog to clean")
+	return func(args []string) error {
+		dir, err := catalogArg(*out, args)
+		if err != nil {
+			return err
 		}
-	}()
-	walker := &asset.SVGWalker{
-		Catalog:       c,
-		ForceUpdate:   force,
-		SanitizePaths: sanitize,
-		Converter:     converter,
-	}
-	if err := walker.Walk(flag.Args()[0]); err != nil {
+		removed, err := asset.CleanCatalog(dir)
+		fmt.Printf("Removed %d folders from %s\n", len(removed), dir)
 		return err
 	}
-	if appIcon != "" {
-		if err := walker.AddAppIconSVG(appIcon); err != nil {
+}
+
+func infoFlags(fs *flag.FlagSet) func([]string) error {
+	out := fs.String("out", "", "Catalog to summarize")
+	return func(args []string) error {
+		dir, err := catalogArg(*out, args)
+		if err != nil {
+			return err
+		}
+		s, err := asset.Summarize(dir)
+		if err != nil {
 			return err
 		}
+		kinds := make([]string, 0, len(s.Sets))
+		for k := range s.Sets {
+			kinds = append(kinds, k)
+		}
+		sort.Strings(kinds)
+		fmt.Printf("%s\n%d groups\n", dir, s.Groups)
+		for _, k := range kinds {
+			fmt.Printf("%d %s\n", s.Sets[k], k)
+		}
+		fmt.Printf("%d files\t%d bytes\n", s.Files, s.Bytes)
+		return nil
 	}
-	return c.Write()
 }
 
-func main() {
-	var (
-		out, appIcon             string
-		force, verbose, sanitize bool
-	)
-	flag.StringVar(&out, "out", "", "Output directory for the asset catalog")
-	flag.StringVar(&appIcon, "appicon", "", "Path to the SVG to use as an app icon")
-	flag.BoolVar(&force, "force", false, "If true all svgs are updated")
-	flag.BoolVar(&verbose, "v", false, "If true verbose output is printed")
-	flag.BoolVar(&sanitize, "sanitize", false, "If true any spaces in paths are converted into _")
-	flag.Parse()
-
-	if err := validate(out); err != nil {
-		fmt.Fprintf(os.Stderr, "Usage: %s --out <path/to/Catalog.xcassets> <src>\n", os.Args[0])
-		flag.Usage()
-		os.Exit(1)
-		return
+type globs []string
+
+func (g *globs) String() string { return strings.Join(*g, " ") }
+
+func (g *globs) Set(v string) error {
+	*g = append(*g, v)
+	return nil
+}
+
+type tagRules []asset.TagRule
+
+func (t *tagRules) String() string {
+	rules := make([]string, len(*t))
+	for i, r := range *t {
+		rules[i] = r.Pattern + "=" + strings.Join(r.Tags, ",")
 	}
+	return strings.Join(rules, " ")
+}
 
-	if verbose {
-		asset.Log = func(args ...interface{}) { fmt.Println(args...) }
+func (t *tagRules) Set(v string) error {
+	idx := strings.LastIndex(v, "=")
+	if idx <= 0 || idx == len(v)-1 {
+		return fmt.Errorf("%s: expected <glob or folder>=<tag>[,<tag>...]", v)
 	}
+	*t = append(*t, asset.TagRule{Pattern: v[:idx], Tags: strings.Split(v[idx+1:], ",")})
+	return nil
+}
+
+type recolorRules []asset.RecolorRule
 
-	err := gen(out, appIcon, force, sanitize)
-	if err != nil {
+func (r *recolorRules) String() string {
+	rules := make([]string, len(*r))
+	for i, rule := range *r {
+		colors := make([]string, 0, len(rule.Colors))
+		for from, to := range rule.Colors {
+			colors = append(colors, from+">"+to)
+		}
+		sort.Strings(colors)
+		rules[i] = rule.Pattern + "=" + rule.Suffix + ":" + strings.Join(colors, ",")
+	}
+	return strings.Join(rules, " ")
+}
+
+func (r *recolorRules) Set(v string) error {
+	eq := strings.LastIndex(v, "=")
+	colon := strings.Index(v[eq+1:], ":")
+	if eq <= 0 || colon <= 0 || eq+colon+2 == len(v) {
+		return fmt.Errorf("%s: expected <glob>=<suffix>:[<from>>]<to>[,[<from>>]<to>...]", v)
+	}
+	colors := asset.ColorMap{}
+	for _, c := range strings.Split(v[eq+colon+2:], ",") {
+		from, to := "*", c
+		if i := strings.Index(c, ">"); i >= 0 {
+			from, to = c[:i], c[i+1:]
+		}
+		colors[from] = to
+	}
+	*r = append(*r, asset.RecolorRule{Pattern: v[:eq], Suffix: v[eq+1 : eq+1+colon], Colors: colors})
+	return nil
+}
+
+// commandFor returns the command named by the first argument along with
+// its arguments. Without a command name every argument is passed to build,
+// as before commands were added.
+func commandFor(args []string) (command, []string) {
+	if len(args) > 0 {
+		for _, c := range commands {
+			if c.name == args[0] {
+				return c, args[1:]
+			}
+		}
+	}
+	return commands[1], args
+}
+
+func main() {
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case "help", "-h", "-help", "--help":
+			usage()
+			return
+		}
+	}
+	if err := run(commandFor(args)); err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
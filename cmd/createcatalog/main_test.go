@@ -0,0 +1,89 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandFor(t *testing.T) {
+	for _, name := range []string{"init", "build", "serve", "lint", "clean", "info", "preview"} {
+		cmd, args := commandFor([]string{name, "-out", "A.xcassets"})
+		require.Equal(t, name, cmd.name)
+		require.Equal(t, []string{"-out", "A.xcassets"}, args)
+	}
+	// Without a command, every argument goes to build.
+	cmd, args := commandFor([]string{"-out", "A.xcassets", "src"})
+	require.Equal(t, "build", cmd.name)
+	require.Equal(t, []string{"-out", "A.xcassets", "src"}, args)
+	cmd, args = commandFor(nil)
+	require.Equal(t, "build", cmd.name)
+	require.Empty(t, args)
+}
+
+func TestCatalogArg(t *testing.T) {
+	dir, err := catalogArg("A.xcassets/", nil)
+	require.NoError(t, err)
+	require.Equal(t, "A.xcassets", dir)
+	// An argument wins over -out, which may come from a config file.
+	dir, err = catalogArg("A.xcassets", []string{"B.xcassets"})
+	require.NoError(t, err)
+	require.Equal(t, "B.xcassets", dir)
+
+	for _, args := range [][]string{nil, {"A.xcassets", "B.xcassets"}, {"A"}} {
+		_, err := catalogArg("", args)
+		require.Error(t, err, "%v", args)
+	}
+}
+
+func TestRun(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "createcatalog-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(tmpDir))
+	defer os.Chdir(wd)
+
+	require.NoError(t, os.Mkdir("src", 0700))
+	svg := `<svg xmlns="http://www.w3.org/2000/svg" width="30" height="40"></svg>`
+	require.NoError(t, ioutil.WriteFile(filepath.Join("src", "lock.svg"), []byte(svg), 0600))
+	config := "out: Out.xcassets\nconverter: native\n"
+	require.NoError(t, ioutil.WriteFile(defaultConfigFile, []byte(config), 0600))
+
+	require.NoError(t, run(commandFor([]string{"init"})))
+	_, err = os.Stat(filepath.Join("Out.xcassets", "Contents.json"))
+	require.NoError(t, err)
+	require.Error(t, run(commandFor([]string{"init"})))
+
+	// The pre-command form builds, taking -out from the config file.
+	require.NoError(t, run(commandFor([]string{"src"})))
+	_, err = os.Stat(filepath.Join("Out.xcassets", "lock.imageset", "lock-2x.png"))
+	require.NoError(t, err)
+
+	// Lint leaves the catalog alone, even with options that would re-render.
+	require.NoError(t, ioutil.WriteFile("logo.svg", []byte(svg), 0600))
+	require.NoError(t, run(commandFor([]string{"-launch-background", "logo.svg", "src"})))
+	before := listTree(t, "Out.xcassets")
+	require.NoError(t, run(commandFor([]string{"lint", "-launch-background", "logo.svg", "-launch-color", "#000000", "src"})))
+	require.Equal(t, before, listTree(t, "Out.xcassets"))
+	require.NoError(t, run(commandFor([]string{"clean"})))
+	_, err = os.Stat(filepath.Join("Out.xcassets", "lock.imageset"))
+	require.True(t, os.IsNotExist(err))
+}
+
+// listTree maps the files below dir to their modification times.
+func listTree(t *testing.T, dir string) map[string]time.Time {
+	files := map[string]time.Time{}
+	require.NoError(t, filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil {
+			files[path] = info.ModTime()
+		}
+		return err
+	}))
+	return files
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/surullabs/asset"
+)
+
+func previewFlags(fs *flag.FlagSet) func([]string) error {
+	out := fs.String("out", "", "Catalog to preview")
+	html := fs.String("html", "", "HTML file to write (default <catalog>.html next to the catalog)")
+	embed := fs.Bool("embed", false, "If true images are embedded so that the page is a single file")
+	return func(args []string) error {
+		catalog, err := catalogArg(*out, args)
+		if err != nil {
+			return err
+		}
+		return preview(catalog, *html, *embed)
+	}
+}
+
+// preview writes an HTML contact sheet of an existing catalog.
+func preview(catalog, html string, embed bool) error {
+	if html == "" {
+		html = strings.TrimSuffix(catalog, ".xcassets") + ".html"
+	}
+	f, err := os.Create(html)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	err = asset.WriteContactSheet(w, catalog, asset.ContactSheetOptions{Embed: embed, LinkDir: filepath.Dir(html)})
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/surullabs/asset"
+)
+
+func serveFlags(fs *flag.FlagSet) func([]string) error {
+	o := buildFlags(fs)
+	addr := fs.String("addr", "localhost:8080", "Address the catalog preview is served on")
+	poll := fs.Duration("poll", time.Second, "How often sources are checked for changes")
+	return func(args []string) error {
+		if o.out == "" {
+			return errors.New("no output directory specifed")
+		}
+		if err := o.setup(args); err != nil {
+			return err
+		}
+		stop, err := o.startConverter()
+		if err != nil {
+			return err
+		}
+		defer stop()
+		return serve(*addr, *poll, o.out, o.watched(), o.build)
+	}
+}
+
+// fingerprint hashes the path, size and modification time of every file
+// below the given paths, skipping the skip directory so that writing the
+// catalog does not count as a change.
+func fingerprint(paths []string, skip string) (string, error) {
+	skip, err := filepath.Abs(skip)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, p := range paths {
+		if p, err = filepath.Abs(p); err != nil {
+			return "", err
+		}
+		err := filepath.Walk(p, func(path string, info os.FileInfo, err error) error {
+			if os.IsNotExist(err) {
+				fmt.Fprintf(h, "%s missing\n", path)
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if info.IsDir() && path == skip {
+				return filepath.SkipDir
+			}
+			fmt.Fprintf(h, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// serve builds the catalog at out and serves it on addr, polling the
+// watched paths for changes. Changes rebuild the catalog and open pages
+// reload after every successful rebuild.
+func serve(addr string, poll time.Duration, out string, watched []string, build func() error) error {
+	stamp, err := fingerprint(watched, out)
+	if err != nil {
+		return err
+	}
+	if err := build(); err != nil {
+		return err
+	}
+	server := asset.NewCatalogServer(out)
+	go func() {
+		for range time.Tick(poll) {
+			next, err := fingerprint(watched, out)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				continue
+			}
+			if next == stamp {
+				continue
+			}
+			stamp = next
+			if err := build(); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				continue
+			}
+			server.Reload()
+		}
+	}()
+	fmt.Printf("Serving %s on http://%s/\n", out, addr)
+	return http.ListenAndServe(addr, server)
+}
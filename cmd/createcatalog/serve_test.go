@@ -0,0 +1,32 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "createcatalog-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	out := filepath.Join(tmpDir, "Out.xcassets")
+	require.NoError(t, os.Mkdir(out, 0700))
+	stamp, err := fingerprint([]string{tmpDir}, out)
+	require.NoError(t, err)
+
+	// Writing the catalog inside a watched folder is not a change.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(out, "Contents.json"), []byte("{}"), 0600))
+	next, err := fingerprint([]string{tmpDir}, out)
+	require.NoError(t, err)
+	require.Equal(t, stamp, next)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "icon.svg"), []byte("<svg/>"), 0600))
+	next, err = fingerprint([]string{tmpDir}, out)
+	require.NoError(t, err)
+	require.NotEqual(t, stamp, next)
+}
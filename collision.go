@@ -0,0 +1,63 @@
+package asset
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// nameClaim records the source that first produced an asset name. Sources
+// sharing an identity, such as the @2x and @3x files of a raster set, may
+// claim the same name.
+type nameClaim struct {
+	identity string
+	source   string
+}
+
+// claimName fails if a different source already produced an asset whose
+// Xcode name differs from target only in case or is the same after
+// sanitization. kind names the asset type in errors. Names inside groups
+// that do not provide a namespace are global, so they are compared across
+// the whole catalog.
+func (s *SVGWalker) claimName(c *Container, kind, target, identity, source string) error {
+	name := c.namespace + target
+	key := strings.ToLower(name)
+	if existing, ok := s.names[key]; ok && existing.identity != identity {
+		return errors.Errorf("%s and %s: both map to %s %q", existing.source, source, kind, name)
+	}
+	if s.names == nil {
+		s.names = map[string]nameClaim{}
+	}
+	s.names[key] = nameClaim{identity, source}
+	return nil
+}
+
+// imageSet returns the image set named target in c after claiming its name.
+func (s *SVGWalker) imageSet(c *Container, target, identity, source string) (*ImageSet, error) {
+	if err := s.claimName(c, "image set", target, identity, source); err != nil {
+		return nil, err
+	}
+	image := c.Images[target]
+	if image == nil {
+		var err error
+		img := filepath.Join(c.Dir, target+".imageset")
+		if image, err = NewImageSet(img); err != nil {
+			return nil, err
+		}
+		c.Images[target] = image
+	}
+	return image, nil
+}
+
+// checkGroupCase fails if c already has a group whose name differs from name
+// only in case, since both would be written to the same folder on a case
+// insensitive file system.
+func (c *Container) checkGroupCase(name, source string) error {
+	for existing := range c.Groups {
+		if existing != name && strings.EqualFold(existing, name) {
+			return errors.Errorf("%s: group %q clashes with existing group %q", source, name, existing)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package asset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSVGWalker_Collisions(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		files    map[string]string
+		sanitize bool
+		err      string
+	}{
+		{
+			name:     "sanitized",
+			files:    map[string]string{"foo bar.svg": testSVG, "foo_bar.svg": testSVG},
+			sanitize: true,
+			err:      `both map to image set "foo_bar"`,
+		},
+		{
+			name:  "case",
+			files: map[string]string{"Lock.svg": testSVG, "lock.svg": testSVG},
+			err:   `both map to image set`,
+		},
+		{
+			name: "global names",
+			files: map[string]string{
+				"a/asset.yaml": "provides-namespace: false\n",
+				"a/lock.svg":   testSVG,
+				"b/asset.yaml": "provides-namespace: false\n",
+				"b/lock.svg":   testSVG,
+			},
+			err: `both map to image set "lock"`,
+		},
+		{
+			name: "namespaced",
+			files: map[string]string{
+				"a/lock.svg": testSVG,
+				"b/lock.svg": testSVG,
+				"lock.svg":   testSVG,
+			},
+		},
+		{
+			name:  "svg and raster",
+			files: map[string]string{"lock.svg": testSVG, "lock@2x.png": ""},
+			err:   `both map to image set "lock"`,
+		},
+		{
+			name:  "group case",
+			files: map[string]string{"Icons/a.svg": testSVG, "icons/b.svg": testSVG},
+			err:   `clashes with existing group`,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			tmpDir, err := ioutil.TempDir("", "collision-test")
+			require.NoError(t, err)
+			defer os.RemoveAll(tmpDir)
+
+			src := filepath.Join(tmpDir, "src")
+			writeTree(t, src, c.files)
+			walker := &SVGWalker{
+				Converter:     &recordingConverter{},
+				Catalog:       newTestCatalog(t, tmpDir),
+				SanitizePaths: c.sanitize,
+			}
+			err = walker.Walk(src)
+			if c.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), c.err)
+		})
+	}
+}
+
+func TestSVGWalker_RasterSetIsNotACollision(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "collision-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTestPNG(t, filepath.Join(src, "hero@2x.png"), 4, 4)
+	writeTestPNG(t, filepath.Join(src, "hero@3x.png"), 6, 6)
+	walker := &SVGWalker{Converter: &recordingConverter{}, Catalog: newTestCatalog(t, tmpDir)}
+	require.NoError(t, walker.Walk(src))
+	require.NoError(t, walker.Walk(src))
+}
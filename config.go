@@ -0,0 +1,413 @@
+package asset
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigFileNames lists the per-directory configuration files read by
+// SVGWalker. The first one found in a directory is used.
+var ConfigFileNames = []string{"asset.yaml", ".asset.json"}
+
+// Config controls how the sources in a directory, and every directory below
+// it, are added to a catalog. Unset fields inherit the parent's value.
+type Config struct {
+	Scales               []int           `json:"scales,omitempty" yaml:"scales,omitempty"`
+	Idiom                string          `json:"idiom,omitempty" yaml:"idiom,omitempty"`
+	ProvidesNamespace    *bool           `json:"provides-namespace,omitempty" yaml:"provides-namespace,omitempty"`
+	OnDemandResourceTags []string        `json:"on-demand-resource-tags,omitempty" yaml:"on-demand-resource-tags,omitempty"`
+	Sanitize             *bool           `json:"sanitize,omitempty" yaml:"sanitize,omitempty"`
+	Sanitizer            string          `json:"sanitizer,omitempty" yaml:"sanitizer,omitempty"`
+	ImageSetName         string          `json:"image-set-name,omitempty" yaml:"image-set-name,omitempty"`
+	FileName             string          `json:"file-name,omitempty" yaml:"file-name,omitempty"`
+	Quantize             *bool           `json:"quantize,omitempty" yaml:"quantize,omitempty"`
+	QuantizeMaxError     float64         `json:"quantize-max-error,omitempty" yaml:"quantize-max-error,omitempty"`
+	Include              []string        `json:"include,omitempty" yaml:"include,omitempty"`
+	Exclude              []string        `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+	Size                 *Size           `json:"size,omitempty" yaml:"size,omitempty"`
+	Sizes                map[string]Size `json:"sizes,omitempty" yaml:"sizes,omitempty"`
+	// Slices maps globs to the cap and alignment insets of matching sources.
+	Slices map[string]Slice `json:"slices,omitempty" yaml:"slices,omitempty"`
+	// Locales lists folders, such as fr or en-GB, whose sources are
+	// localized entries of the image sets in the enclosing folder.
+	Locales []string `json:"locales,omitempty" yaml:"locales,omitempty"`
+	// MirrorRTL generates a right-to-left variant of every SVG without one
+	// by mirroring it horizontally.
+	MirrorRTL *bool `json:"mirror-rtl,omitempty" yaml:"mirror-rtl,omitempty"`
+	// Data lists globs of files copied into data sets, such as "*.json".
+	Data []string `json:"data,omitempty" yaml:"data,omitempty"`
+	// Symbols lists globs of SVG glyphs turned into custom symbol sets.
+	Symbols []string `json:"symbols,omitempty" yaml:"symbols,omitempty"`
+	// Sprites lists globs of SVG sprite sheets split into an image set per
+	// <symbol>.
+	Sprites []string `json:"sprites,omitempty" yaml:"sprites,omitempty"`
+	// Recolor maps globs to suffixes and the colors of the recolored image
+	// set each suffix adds, such as lock-danger for lock.svg.
+	Recolor map[string]map[string]ColorMap `json:"recolor,omitempty" yaml:"recolor,omitempty"`
+}
+
+type Size struct {
+	Width  float32 `json:"width" yaml:"width"`
+	Height float32 `json:"height" yaml:"height"`
+}
+
+func ReadConfig(dir string) (*Config, error) {
+	for _, name := range ConfigFileNames {
+		path := filepath.Join(dir, name)
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "%s: failed to read config", path)
+		}
+		c := &Config{}
+		if filepath.Ext(name) == ".json" {
+			dec := json.NewDecoder(bytes.NewReader(data))
+			dec.DisallowUnknownFields()
+			err = dec.Decode(c)
+		} else {
+			err = yaml.UnmarshalStrict(data, c)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: failed to parse config", path)
+		}
+		return c, nil
+	}
+	return nil, nil
+}
+
+type globRule struct {
+	base    string
+	pattern string
+}
+
+// match matches pattern against path relative to base. Patterns without
+// a separator also match the file name alone.
+func (g globRule) match(path string) bool {
+	rel, err := filepath.Rel(g.base, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	if ok, _ := filepath.Match(g.pattern, rel); ok {
+		return true
+	}
+	if strings.ContainsRune(g.pattern, filepath.Separator) {
+		return false
+	}
+	ok, _ := filepath.Match(g.pattern, filepath.Base(rel))
+	return ok
+}
+
+type sizeRule struct {
+	globRule
+	size Size
+}
+
+// settings is the result of merging every Config from the walk root down to
+// a directory.
+type settings struct {
+	scales       []int
+	idiom        string
+	namespace    *bool
+	tags         []string
+	sanitizer    Sanitizer
+	sanitize     bool
+	include      []globRule
+	exclude      []globRule
+	size         *Size
+	sizes        []sizeRule
+	slices       []sliceRule
+	imageSetName *template.Template
+	fileName     *template.Template
+	appIconFile  *template.Template
+
+	quantize         bool
+	quantizeMaxError float64
+
+	locales   []string
+	mirrorRTL bool
+	data      []globRule
+	symbols   []globRule
+	sprites   []globRule
+	recolor   []recolorRule
+}
+
+func (s *SVGWalker) rootSettings() (*settings, error) {
+	p := &settings{
+		scales:    []int{1, 2, 3},
+		idiom:     "universal",
+		sanitizer: s.Sanitizer,
+		sanitize:  s.SanitizePaths || s.Sanitizer != nil,
+		mirrorRTL: s.MirrorRTL,
+	}
+	if p.sanitizer == nil {
+		p.sanitizer = Sanitizers["spaces"]
+	}
+	for _, g := range s.Data {
+		p.data = append(p.data, globRule{".", g})
+	}
+	for _, g := range s.Symbols {
+		p.symbols = append(p.symbols, globRule{".", g})
+	}
+	for _, g := range s.Sprites {
+		p.sprites = append(p.sprites, globRule{".", g})
+	}
+	for _, r := range s.Recolor {
+		rule, err := newRecolorRule(".", r.Pattern, r.Suffix, r.Colors)
+		if err != nil {
+			return nil, err
+		}
+		p.recolor = append(p.recolor, rule)
+	}
+	var err error
+	if p.imageSetName, err = parseNameTemplate("image-set-name", s.ImageSetName, DefaultImageSetName); err != nil {
+		return nil, err
+	}
+	if p.fileName, err = parseNameTemplate("file-name", s.FileName, DefaultFileName); err != nil {
+		return nil, err
+	}
+	if p.appIconFile, err = parseNameTemplate("app-icon-file-name", s.AppIconFileName, DefaultAppIconFileName); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// merge returns a copy of p with c, read from base, applied on top.
+func (p *settings) merge(base string, c *Config) (*settings, error) {
+	m := *p
+	if c == nil {
+		return &m, nil
+	}
+	if len(c.Scales) > 0 {
+		m.scales = c.Scales
+	}
+	if c.Idiom != "" {
+		m.idiom = c.Idiom
+	}
+	if c.ProvidesNamespace != nil {
+		m.namespace = c.ProvidesNamespace
+	}
+	if len(c.OnDemandResourceTags) > 0 {
+		m.tags = appendUnique(append([]string(nil), p.tags...), c.OnDemandResourceTags...)
+	}
+	if c.Sanitize != nil {
+		m.sanitize = *c.Sanitize
+	}
+	if c.Sanitizer != "" {
+		sanitizer, err := ParseSanitizer(c.Sanitizer)
+		if err != nil {
+			return nil, err
+		}
+		m.sanitizer, m.sanitize = sanitizer, sanitizer != nil
+	}
+	var err error
+	if c.ImageSetName != "" {
+		if m.imageSetName, err = parseNameTemplate("image-set-name", c.ImageSetName, ""); err != nil {
+			return nil, err
+		}
+	}
+	if c.FileName != "" {
+		if m.fileName, err = parseNameTemplate("file-name", c.FileName, ""); err != nil {
+			return nil, err
+		}
+	}
+	if len(c.Include) > 0 {
+		m.include = nil
+		for _, g := range c.Include {
+			m.include = append(m.include, globRule{base, g})
+		}
+	}
+	if len(c.Exclude) > 0 {
+		m.exclude = append([]globRule(nil), p.exclude...)
+		for _, g := range c.Exclude {
+			m.exclude = append(m.exclude, globRule{base, g})
+		}
+	}
+	if c.Size != nil {
+		m.size = c.Size
+	}
+	if c.Quantize != nil {
+		m.quantize = *c.Quantize
+	}
+	if c.QuantizeMaxError != 0 {
+		m.quantizeMaxError = c.QuantizeMaxError
+	}
+	if len(c.Locales) > 0 {
+		m.locales = appendUnique(append([]string(nil), p.locales...), c.Locales...)
+	}
+	if c.MirrorRTL != nil {
+		m.mirrorRTL = *c.MirrorRTL
+	}
+	if len(c.Data) > 0 {
+		m.data = append([]globRule(nil), p.data...)
+		for _, g := range c.Data {
+			m.data = append(m.data, globRule{base, g})
+		}
+	}
+	if len(c.Symbols) > 0 {
+		m.symbols = append([]globRule(nil), p.symbols...)
+		for _, g := range c.Symbols {
+			m.symbols = append(m.symbols, globRule{base, g})
+		}
+	}
+	if len(c.Sprites) > 0 {
+		m.sprites = append([]globRule(nil), p.sprites...)
+		for _, g := range c.Sprites {
+			m.sprites = append(m.sprites, globRule{base, g})
+		}
+	}
+	if len(c.Recolor) > 0 {
+		m.recolor = append([]recolorRule(nil), p.recolor...)
+		globs := make([]string, 0, len(c.Recolor))
+		for g := range c.Recolor {
+			globs = append(globs, g)
+		}
+		sort.Strings(globs)
+		for _, g := range globs {
+			suffixes := make([]string, 0, len(c.Recolor[g]))
+			for suffix := range c.Recolor[g] {
+				suffixes = append(suffixes, suffix)
+			}
+			sort.Strings(suffixes)
+			for _, suffix := range suffixes {
+				rule, err := newRecolorRule(base, g, suffix, c.Recolor[g][suffix])
+				if err != nil {
+					return nil, err
+				}
+				m.recolor = append(m.recolor, rule)
+			}
+		}
+	}
+	if len(c.Sizes) > 0 {
+		m.sizes = append([]sizeRule(nil), p.sizes...)
+		globs := make([]string, 0, len(c.Sizes))
+		for g := range c.Sizes {
+			globs = append(globs, g)
+		}
+		sort.Strings(globs)
+		for _, g := range globs {
+			m.sizes = append(m.sizes, sizeRule{globRule{base, g}, c.Sizes[g]})
+		}
+	}
+	if len(c.Slices) > 0 {
+		m.slices = append([]sliceRule(nil), p.slices...)
+		globs := make([]string, 0, len(c.Slices))
+		for g := range c.Slices {
+			globs = append(globs, g)
+		}
+		sort.Strings(globs)
+		for _, g := range globs {
+			slice := c.Slices[g]
+			if err := slice.validate(filepath.Join(base, g)); err != nil {
+				return nil, err
+			}
+			m.slices = append(m.slices, sliceRule{globRule{base, g}, slice})
+		}
+	}
+	return &m, nil
+}
+
+func (p *settings) includes(file string) bool {
+	for _, g := range p.exclude {
+		if g.match(file) {
+			return false
+		}
+	}
+	if len(p.include) == 0 {
+		return true
+	}
+	for _, g := range p.include {
+		if g.match(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// sizeFor returns the size override for file, preferring the most recently
+// merged matching rule.
+func (p *settings) sizeFor(file string) *Size {
+	for i := len(p.sizes) - 1; i >= 0; i-- {
+		if p.sizes[i].match(file) {
+			return &p.sizes[i].size
+		}
+	}
+	return p.size
+}
+
+func (p *settings) sanitized(path string) string {
+	if !p.sanitize || p.sanitizer == nil {
+		return path
+	}
+	return p.sanitizer(path)
+}
+
+// imageSetNameFor returns the sanitized and templated image set name for a
+// source named name.
+func (p *settings) imageSetNameFor(name string) (string, error) {
+	return executeName(p.imageSetName, NameData{Name: p.sanitized(name)})
+}
+
+func (p *settings) fileNameFor(imageSet string, scale int, v variant) (string, error) {
+	return executeName(p.fileName, NameData{Name: imageSet, Scale: scale, Idiom: v.idiom, Variant: v.name})
+}
+
+// settingsFor merges the configs found in root and every directory leading
+// to rel.
+func (s *SVGWalker) settingsFor(root, rel string) (*settings, error) {
+	key := filepath.Join(root, rel)
+	if cached := s.settings[key]; cached != nil {
+		return cached, nil
+	}
+	var (
+		parent *settings
+		err    error
+	)
+	if rel == "." || rel == "" {
+		parent, err = s.rootSettings()
+	} else {
+		parent, err = s.settingsFor(root, filepath.Dir(rel))
+	}
+	if err != nil {
+		return nil, err
+	}
+	c, err := ReadConfig(key)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := parent.merge(filepath.Clean(rel), c)
+	if err != nil {
+		return nil, errors.Wrap(err, key)
+	}
+	if s.settings == nil {
+		s.settings = map[string]*settings{}
+	}
+	s.settings[key] = merged
+	return merged, nil
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, l := range list {
+			if l == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
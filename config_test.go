@@ -0,0 +1,113 @@
+package asset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingConverter struct {
+	calls []fakeConvertCall
+}
+
+func (r *recordingConverter) Convert(scale int, height, width float32, svg, png string) error {
+	r.calls = append(r.calls, fakeConvertCall{scale, height, width, svg, png, ""})
+	return ioutil.WriteFile(png, []byte("png"), 0600)
+}
+
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0600))
+	}
+}
+
+func newTestCatalog(t *testing.T, tmpDir string) *Catalog {
+	dir := filepath.Join(tmpDir, "Test.xcassets")
+	require.NoError(t, os.MkdirAll(dir, 0700))
+	c, err := NewCatalog(dir)
+	require.NoError(t, err)
+	return c
+}
+
+const testSVG = `<svg xmlns="http://www.w3.org/2000/svg" width="30" height="40"></svg>`
+
+func TestSVGWalker_Config(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "config-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{
+		"asset.yaml":         "scales: [2, 3]\non-demand-resource-tags: [base]\n",
+		"top.svg":            testSVG,
+		"a b/.asset.json":    `{"provides-namespace": false, "sanitize": true, "idiom": "iphone", "exclude": ["draft-*"], "on-demand-resource-tags": ["extra"]}`,
+		"a b/draft-one.svg":  testSVG,
+		"a b/c/asset.yaml":   "scales: [1]\nsizes:\n  'icon*.svg': {width: 10, height: 12}\n",
+		"a b/c/icon one.svg": testSVG,
+		"a b/c/other.svg":    testSVG,
+	})
+
+	catalog := newTestCatalog(t, tmpDir)
+	conv := &recordingConverter{}
+	walker := &SVGWalker{Converter: conv, Catalog: catalog}
+	require.NoError(t, walker.Walk(src))
+	require.NoError(t, catalog.Write())
+
+	top := catalog.Images["top"]
+	require.NotNil(t, top)
+	require.Len(t, top.Images, 2)
+	require.Equal(t, "2x", top.Images[0].Scale)
+	require.Equal(t, "universal", top.Images[0].Idiom)
+	require.Equal(t, []string{"base"}, top.Properties.OnDemandResourceTags)
+
+	ab := catalog.Groups["a_b"]
+	require.NotNil(t, ab)
+	require.False(t, ab.Properties.ProvidesNamespace)
+	require.Empty(t, ab.Images)
+
+	c := ab.Groups["c"]
+	require.NotNil(t, c)
+	require.False(t, c.Properties.ProvidesNamespace)
+	icon := c.Images["icon_one"]
+	require.NotNil(t, icon)
+	require.Len(t, icon.Images, 1)
+	require.Equal(t, "iphone", icon.Images[0].Idiom)
+	require.Equal(t, []string{"base", "extra"}, icon.Properties.OnDemandResourceTags)
+	require.NotNil(t, c.Images["other"])
+
+	sizes := map[string][2]float32{}
+	for _, call := range conv.calls {
+		sizes[filepath.Base(call.png)] = [2]float32{call.width, call.height}
+	}
+	require.Equal(t, map[string][2]float32{
+		"top-2x.png":      {30, 40},
+		"top-3x.png":      {30, 40},
+		"icon_one-1x.png": {10, 12},
+		"other-1x.png":    {30, 40},
+	}, sizes)
+}
+
+func TestReadConfig_UnknownKeys(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "config-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	writeTree(t, tmpDir, map[string]string{
+		"yaml/asset.yaml":  "scale: [1]\n",
+		"json/.asset.json": `{"scale": [1]}`,
+		"ok/.asset.json":   `{"scales": [1]}`,
+	})
+	for _, dir := range []string{"yaml", "json"} {
+		_, err := ReadConfig(filepath.Join(tmpDir, dir))
+		require.Error(t, err, dir)
+		require.Contains(t, err.Error(), "failed to parse config", dir)
+	}
+	c, err := ReadConfig(filepath.Join(tmpDir, "ok"))
+	require.NoError(t, err)
+	require.Equal(t, []int{1}, c.Scales)
+}
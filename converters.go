@@ -0,0 +1,174 @@
+package asset
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+)
+
+// pixelSize returns the size in pixels of an image of height x width points
+// rendered at scale.
+func pixelSize(scale int, height, width float32) (int, int) {
+	return int(float32(scale) * height), int(float32(scale) * width)
+}
+
+func runConverter(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, string(out))
+	}
+	return nil
+}
+
+var ErrNoRSVG = errors.New("rsvg-convert not installed. rsvg-convert (https://wiki.gnome.org/Projects/LibRsvg) is needed to convert SVG files.")
+
+type RSVGConverter struct{}
+
+func (RSVGConverter) Convert(scale int, height, width float32, svgFile, pngFile string) error {
+	if _, err := exec.LookPath("rsvg-convert"); err != nil {
+		return ErrNoRSVG
+	}
+	h, w := pixelSize(scale, height, width)
+	return runConverter("rsvg-convert",
+		"--format", "png",
+		"--width", strconv.Itoa(w),
+		"--height", strconv.Itoa(h),
+		"--output", pngFile,
+		svgFile)
+}
+
+var ErrNoResvg = errors.New("resvg not installed. resvg (https://github.com/RazrFalcon/resvg) is needed to convert SVG files.")
+
+type ResvgConverter struct{}
+
+func (ResvgConverter) Convert(scale int, height, width float32, svgFile, pngFile string) error {
+	if _, err := exec.LookPath("resvg"); err != nil {
+		return ErrNoResvg
+	}
+	h, w := pixelSize(scale, height, width)
+	return runConverter("resvg",
+		"--width", strconv.Itoa(w),
+		"--height", strconv.Itoa(h),
+		svgFile, pngFile)
+}
+
+var inkscapeVersion = regexp.MustCompile(`Inkscape ([0-9]+)\.`)
+
+// InkScapeVersion returns the major version of the installed inkscape.
+func InkScapeVersion() (int, error) {
+	if _, err := exec.LookPath("inkscape"); err != nil {
+		return 0, ErrNoInkScape
+	}
+	out, err := exec.Command("inkscape", "--version").Output()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to run inkscape --version")
+	}
+	m := inkscapeVersion.FindSubmatch(out)
+	if m == nil {
+		return 0, errors.Errorf("unrecognized inkscape version: %s", strings.TrimSpace(string(out)))
+	}
+	return strconv.Atoi(string(m[1]))
+}
+
+// NewInkScapeConverter returns an InkScapeConverter for the installed
+// version of inkscape.
+func NewInkScapeConverter() (InkScapeConverter, error) {
+	v, err := InkScapeVersion()
+	if err != nil {
+		return InkScapeConverter{}, err
+	}
+	return InkScapeConverter{Major: v, detected: true}, nil
+}
+
+// NativeConverter renders SVGs in pure Go. It supports a subset of SVG and
+// needs no external tools.
+type NativeConverter struct{}
+
+func (NativeConverter) Convert(scale int, height, width float32, svgFile, pngFile string) error {
+	f, err := os.Open(svgFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	icon, err := oksvg.ReadIconStream(f, oksvg.WarnErrorMode)
+	if err != nil {
+		return errors.Wrapf(err, "%s: failed to parse svg", svgFile)
+	}
+	h, w := pixelSize(scale, height, width)
+	icon.SetTarget(0, 0, float64(w), float64(h))
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	scanner := rasterx.NewScannerGV(w, h, img, img.Bounds())
+	icon.Draw(rasterx.NewDasher(w, h, scanner), 1)
+	return writePNG(pngFile, img)
+}
+
+// Converters lists the converter names accepted by StartConverter. "auto"
+// picks the first of resvg, rsvg, chrome, inkscape and phantomjs that is
+// installed, falling back to native.
+var Converters = []string{"auto", "phantomjs", "chrome", "inkscape", "rsvg", "resvg", "native"}
+
+func lookPath(binary string) func() error {
+	return func() error {
+		_, err := exec.LookPath(binary)
+		return err
+	}
+}
+
+// StartConverter returns the named converter along with a function that
+// stops it once conversion is done.
+func StartConverter(name string) (SVGConverter, func() error, error) {
+	noop := func() error { return nil }
+	switch name {
+	case "", "auto":
+		for _, c := range []struct {
+			name      string
+			installed func() error
+		}{
+			{"resvg", lookPath("resvg")},
+			{"rsvg", lookPath("rsvg-convert")},
+			{"chrome", func() error { _, err := FindChrome(); return err }},
+			{"inkscape", lookPath("inkscape")},
+			{"phantomjs", lookPath("phantomjs")},
+		} {
+			if c.installed() == nil {
+				Log("Using converter", c.name)
+				return StartConverter(c.name)
+			}
+		}
+		Log("Using converter", "native")
+		return NativeConverter{}, noop, nil
+	case "phantomjs":
+		p, err := StartPhantomJSConverter()
+		if err != nil {
+			return nil, nil, err
+		}
+		return p, p.Stop, nil
+	case "chrome":
+		c, err := StartChromeConverter()
+		if err != nil {
+			return nil, nil, err
+		}
+		return c, c.Stop, nil
+	case "inkscape":
+		c, err := NewInkScapeConverter()
+		if err != nil {
+			return nil, nil, err
+		}
+		return c, noop, nil
+	case "rsvg":
+		return RSVGConverter{}, noop, nil
+	case "resvg":
+		return ResvgConverter{}, noop, nil
+	case "native":
+		return NativeConverter{}, noop, nil
+	}
+	return nil, nil, errors.Errorf("unknown converter %q (must be one of %s)", name, strings.Join(Converters, ", "))
+}
@@ -0,0 +1,75 @@
+package asset
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/surullabs/asset/golden"
+)
+
+func TestConverters(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "converters-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	for _, c := range []struct {
+		name   string
+		binary string
+	}{
+		{"native", ""},
+		{"rsvg", "rsvg-convert"},
+		{"resvg", "resvg"},
+		{"inkscape", "inkscape"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if c.binary != "" {
+				if _, err := exec.LookPath(c.binary); err != nil {
+					t.Skip(c.binary, "not installed")
+				}
+			}
+			converter, stop, err := StartConverter(c.name)
+			require.NoError(t, err)
+			defer func() { require.NoError(t, stop()) }()
+
+			out := filepath.Join(tmpDir, c.name+".png")
+			require.NoError(t, converter.Convert(2, 30, 20, "testdata/data/lock.svg", out))
+			img, err := decodeImage(out)
+			require.NoError(t, err)
+			require.Equal(t, 40, img.Bounds().Dx())
+			require.Equal(t, 60, img.Bounds().Dy())
+
+			opaque := false
+			for y := 0; y < 60 && !opaque; y++ {
+				for x := 0; x < 40 && !opaque; x++ {
+					_, _, _, a := img.At(x, y).RGBA()
+					opaque = a > 0
+				}
+			}
+			require.True(t, opaque, "nothing rendered")
+		})
+	}
+}
+
+func TestStartConverter_Unknown(t *testing.T) {
+	_, _, err := StartConverter("gimp")
+	require.Error(t, err)
+}
+
+func TestNativeConverter_Golden(t *testing.T) {
+	cases, err := golden.Corpus("testdata/data", "testdata/golden/native", 150, 150, 1, 2, 3)
+	require.NoError(t, err)
+	golden.Run(t, NativeConverter{}, cases, golden.Options{})
+}
+
+func TestInkScapeConverter_Known(t *testing.T) {
+	require.False(t, InkScapeConverter{}.known())
+	require.True(t, InkScapeConverter{Major: 1}.known())
+	// A detected 0.x release is not detected again.
+	detected := InkScapeConverter{detected: true}
+	require.True(t, detected.known())
+	require.Equal(t, "0", detected.Version())
+}
@@ -0,0 +1,171 @@
+package asset
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DataTypes maps lower case file extensions to the universal type
+// identifiers of data set entries. Other files are marked public.data.
+var DataTypes = map[string]string{
+	".json":    "public.json",
+	".txt":     "public.plain-text",
+	".csv":     "public.comma-separated-values-text",
+	".xml":     "public.xml",
+	".html":    "public.html",
+	".yaml":    "public.yaml",
+	".yml":     "public.yaml",
+	".plist":   "com.apple.property-list",
+	".mlmodel": "com.apple.coreml.model",
+	".png":     "public.png",
+	".jpg":     "public.jpeg",
+	".jpeg":    "public.jpeg",
+	".gif":     "com.compuserve.gif",
+	".svg":     "public.svg-image",
+	".pdf":     "com.adobe.pdf",
+	".mp3":     "public.mp3",
+	".m4a":     "com.apple.m4a-audio",
+	".wav":     "com.microsoft.waveform-audio",
+	".mp4":     "public.mpeg-4",
+	".mov":     "com.apple.quicktime-movie",
+	".ttf":     "public.truetype-ttf-font",
+	".otf":     "public.opentype-font",
+	".zip":     "public.zip-archive",
+}
+
+func dataType(file string) string {
+	if uti, ok := DataTypes[strings.ToLower(filepath.Ext(file))]; ok {
+		return uti
+	}
+	return "public.data"
+}
+
+// isData reports whether file is copied into a data set rather than
+// rendered.
+func (p *settings) isData(file string) bool {
+	for _, name := range ConfigFileNames {
+		if filepath.Base(file) == name {
+			return false
+		}
+	}
+	for _, g := range p.data {
+		if g.match(file) {
+			return true
+		}
+	}
+	return false
+}
+
+func dataSlot(d Data) string {
+	return strings.Join([]string{d.Idiom, d.Memory, d.GraphicsFeatureSet}, "|")
+}
+
+// addData copies file, relative to dir, into a data set in c named after it.
+// Like SVGs, files may carry idiom, memory and graphics feature set
+// qualifiers, such as model~4gb.mlmodel.
+func (s *SVGWalker) addData(c *Container, dir, file string, cfg *settings, identity, locale string) error {
+	path := filepath.Join(dir, file)
+	if locale != "" {
+		return errors.Errorf("%s: data sets cannot be localized", path)
+	}
+	name := filepath.Base(file)
+	base, v, err := parseVariant(strings.TrimSuffix(name, filepath.Ext(name)), cfg.idiom)
+	if err != nil {
+		return err
+	}
+	if v.subtype != "" || v.widthClass != "" || v.heightClass != "" || v.direction != "" {
+		return errors.Errorf("%s: data sets only support idiom, memory and graphics feature set variants", path)
+	}
+	target := cfg.sanitized(base)
+	if err := s.claimName(c, "data set", target, filepath.Join(identity, base+"@data"), path); err != nil {
+		return err
+	}
+	set := c.DataSets[target]
+	if set == nil {
+		if set, err = NewDataSet(filepath.Join(c.Dir, target+".dataset")); err != nil {
+			return err
+		}
+		c.DataSets[target] = set
+	}
+	set.Properties.OnDemandResourceTags = s.resourceTags(cfg, file)
+
+	entry := Data{
+		FileName:                name,
+		Idiom:                   v.idiom,
+		UniversalTypeIdentifier: dataType(name),
+		Memory:                  v.memory,
+		GraphicsFeatureSet:      v.graphicsFeatureSet,
+	}
+	slot := dataSlot(entry)
+	if s.dataClaims == nil {
+		s.dataClaims = map[*DataSet]map[string]string{}
+	}
+	claims := s.dataClaims[set]
+	if claims == nil {
+		claims = map[string]string{}
+		s.dataClaims[set] = claims
+	}
+	if existing, ok := claims[slot]; ok && existing != path {
+		return errors.Errorf("%s and %s: both provide the same variant of %s", existing, path, filepath.Base(set.Dir))
+	}
+	claims[slot] = path
+
+	update, err := s.dataNeedsUpdate(set, entry, path)
+	if err != nil || !update {
+		return err
+	}
+	out := filepath.Join(set.Dir, name)
+	entry.generator = func() error {
+		Log("Copying", out)
+		return copyFile(path, out)
+	}
+	for i, d := range set.Data {
+		if dataSlot(d) == slot {
+			set.Data[i] = entry
+			return nil
+		}
+	}
+	set.Data = append(set.Data, entry)
+	return nil
+}
+
+func (s *SVGWalker) dataNeedsUpdate(set *DataSet, entry Data, path string) (bool, error) {
+	if s.ForceUpdate {
+		return true, nil
+	}
+	for _, d := range set.Data {
+		if dataSlot(d) != dataSlot(entry) {
+			continue
+		}
+		if d.FileName != entry.FileName || d.UniversalTypeIdentifier != entry.UniversalTypeIdentifier {
+			return true, nil
+		}
+		src, err := os.Stat(path)
+		if err != nil {
+			return false, err
+		}
+		dst, err := os.Stat(filepath.Join(set.Dir, d.FileName))
+		if err != nil {
+			return true, nil
+		}
+		return dst.ModTime().Before(src.ModTime()), nil
+	}
+	return true, nil
+}
+
+// pruneData drops entries of data sets produced during the walk whose file
+// no longer exists.
+func (s *SVGWalker) pruneData() {
+	for set, claims := range s.dataClaims {
+		kept := set.Data[:0]
+		for _, d := range set.Data {
+			if _, ok := claims[dataSlot(d)]; ok {
+				kept = append(kept, d)
+			}
+		}
+		set.Data = kept
+	}
+}
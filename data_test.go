@@ -0,0 +1,80 @@
+package asset
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSVGWalker_Data(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "data-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{
+		"asset.yaml":           "data: ['*.json']\n",
+		"notes.md":             "ignored",
+		"config.json":          `{"a": 1}`,
+		"config~ipad.json":     `{"a": 2}`,
+		"ml/asset.yaml":        "data: ['*.mlmodel', '*.bin']\n",
+		"ml/model~4gb.mlmodel": "model",
+		"ml/weights.bin":       "weights",
+		"ml/icon.svg":          testSVG,
+	})
+
+	catalog := newTestCatalog(t, tmpDir)
+	walker := &SVGWalker{Converter: &recordingConverter{}, Catalog: catalog}
+	require.NoError(t, walker.Walk(src))
+	require.NoError(t, catalog.Write())
+
+	require.Len(t, catalog.Groups, 1)
+	config := catalog.DataSets["config"]
+	require.NotNil(t, config)
+	data, err := ioutil.ReadFile(filepath.Join(catalog.Dir, "config.dataset", "Contents.json"))
+	require.NoError(t, err)
+	var contents DataSet
+	require.NoError(t, json.Unmarshal(data, &contents))
+	require.Equal(t, []Data{
+		{FileName: "config.json", Idiom: "universal", UniversalTypeIdentifier: "public.json"},
+		{FileName: "config~ipad.json", Idiom: "ipad", UniversalTypeIdentifier: "public.json"},
+	}, contents.Data)
+	copied, err := ioutil.ReadFile(filepath.Join(config.Dir, "config~ipad.json"))
+	require.NoError(t, err)
+	require.Equal(t, `{"a": 2}`, string(copied))
+
+	ml := catalog.Groups["ml"]
+	model := ml.DataSets["model"]
+	require.NotNil(t, model)
+	require.Equal(t, "4GB", model.Data[0].Memory)
+	require.Equal(t, "com.apple.coreml.model", model.Data[0].UniversalTypeIdentifier)
+	require.Equal(t, "public.data", ml.DataSets["weights"].Data[0].UniversalTypeIdentifier)
+	require.NotNil(t, ml.Images["icon"])
+
+	// Removed files drop their entries.
+	require.NoError(t, os.Remove(filepath.Join(src, "config~ipad.json")))
+	catalog = newTestCatalog(t, tmpDir)
+	walker = &SVGWalker{Converter: &recordingConverter{}, Catalog: catalog}
+	require.NoError(t, walker.Walk(src))
+	require.Len(t, catalog.DataSets["config"].Data, 1)
+}
+
+func TestSVGWalker_DataCollision(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "data-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{
+		"logo.json": "{}",
+		"logo.svg":  testSVG,
+	})
+	walker := &SVGWalker{Converter: &recordingConverter{}, Catalog: newTestCatalog(t, tmpDir), Data: []string{"*.json"}}
+	err = walker.Walk(src)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "both map to image set")
+}
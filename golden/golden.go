@@ -0,0 +1,260 @@
+// Package golden renders SVGs through a converter and compares the results
+// against golden PNGs with a per-pixel tolerance, so converter regressions are
+// caught without breaking on harmless encoder changes.
+//
+// Run tests with -update to regenerate the golden images.
+package golden
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var Update = flag.Bool("update", false, "If true golden images are regenerated instead of compared")
+
+// Converter matches asset.SVGConverter.
+type Converter interface {
+	Convert(scale int, height, width float32, svgFile, pngFile string) error
+}
+
+// Case is a single conversion checked against a golden PNG.
+type Case struct {
+	Name   string
+	SVG    string
+	Golden string
+	Scale  int
+	Height float32
+	Width  float32
+}
+
+// Options control how closely a rendering must match its golden image.
+type Options struct {
+	// Threshold is the largest perceptual color difference, between 0 and 1,
+	// for two pixels to be considered equal. Defaults to 0.1.
+	Threshold float64
+	// MaxDiffRatio is the fraction of pixels allowed to differ. Defaults to
+	// 0.001.
+	MaxDiffRatio float64
+	// DiffDir receives a diff image for every failed comparison. Defaults to
+	// golden-diffs in the system temporary directory.
+	DiffDir string
+}
+
+func (o Options) withDefaults() Options {
+	if o.Threshold == 0 {
+		o.Threshold = 0.1
+	}
+	if o.MaxDiffRatio == 0 {
+		o.MaxDiffRatio = 0.001
+	}
+	if o.DiffDir == "" {
+		o.DiffDir = filepath.Join(os.TempDir(), "golden-diffs")
+	}
+	return o
+}
+
+// Corpus returns a case for every SVG below dir at each scale, with goldens
+// stored in goldenDir as <relative path>@<scale>x.png.
+func Corpus(dir, goldenDir string, height, width float32, scales ...int) ([]Case, error) {
+	var cases []Case
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".svg" {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(rel, ".svg")
+		for _, scale := range scales {
+			cases = append(cases, Case{
+				Name:   fmt.Sprintf("%s@%dx", name, scale),
+				SVG:    path,
+				Golden: filepath.Join(goldenDir, fmt.Sprintf("%s@%dx.png", name, scale)),
+				Scale:  scale,
+				Height: height,
+				Width:  width,
+			})
+		}
+		return nil
+	})
+	sort.Slice(cases, func(i, j int) bool { return cases[i].Name < cases[j].Name })
+	return cases, err
+}
+
+// Run converts every case as a subtest and compares it against its golden.
+func Run(t *testing.T, c Converter, cases []Case, opts Options) {
+	opts = opts.withDefaults()
+	tmpDir, err := ioutil.TempDir("", "golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	for i, tc := range cases {
+		tc := tc
+		out := filepath.Join(tmpDir, fmt.Sprintf("%d.png", i))
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := c.Convert(tc.Scale, tc.Height, tc.Width, tc.SVG, out); err != nil {
+				t.Fatalf("failed to convert %s: %v", tc.SVG, err)
+			}
+			if *Update {
+				if err := copyFile(out, tc.Golden); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err := Check(tc.Name, out, tc.Golden, opts); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// Check compares the PNG at actual against golden, writing a diff image to
+// opts.DiffDir when they differ.
+func Check(name, actual, golden string, opts Options) error {
+	opts = opts.withDefaults()
+	want, err := readPNG(golden)
+	if err != nil {
+		return fmt.Errorf("%v (run with -update to create it)", err)
+	}
+	got, err := readPNG(actual)
+	if err != nil {
+		return err
+	}
+	result, diff := Compare(want, got, opts.Threshold)
+	if result.SizeMismatch {
+		return fmt.Errorf("%s: size %v, golden %v", name, got.Bounds().Size(), want.Bounds().Size())
+	}
+	if result.Ratio() <= opts.MaxDiffRatio {
+		return nil
+	}
+	diffFile := filepath.Join(opts.DiffDir, strings.Replace(name, string(filepath.Separator), "_", -1)+".diff.png")
+	if err := writePNG(diffFile, diff); err != nil {
+		return fmt.Errorf("%s: %d of %d pixels differ (failed to write diff: %v)", name, result.Diff, result.Total, err)
+	}
+	return fmt.Errorf("%s: %d of %d pixels differ, see %s", name, result.Diff, result.Total, diffFile)
+}
+
+type Result struct {
+	Diff         int
+	Total        int
+	SizeMismatch bool
+}
+
+func (r Result) Ratio() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(r.Diff) / float64(r.Total)
+}
+
+// Compare counts the pixels whose perceptual difference exceeds threshold
+// and returns an image highlighting them in red over a faded copy of want.
+func Compare(want, got image.Image, threshold float64) (Result, image.Image) {
+	wb, gb := want.Bounds(), got.Bounds()
+	if wb.Size() != gb.Size() {
+		return Result{SizeMismatch: true}, nil
+	}
+	diff := image.NewNRGBA(image.Rect(0, 0, wb.Dx(), wb.Dy()))
+	r := Result{Total: wb.Dx() * wb.Dy()}
+	// The largest possible YIQ delta, used to normalize to [0, 1].
+	const maxDelta = 35215.0
+	for y := 0; y < wb.Dy(); y++ {
+		for x := 0; x < wb.Dx(); x++ {
+			a := want.At(wb.Min.X+x, wb.Min.Y+y)
+			b := got.At(gb.Min.X+x, gb.Min.Y+y)
+			if colorDelta(a, b)/maxDelta > threshold*threshold {
+				r.Diff++
+				diff.SetNRGBA(x, y, color.NRGBA{0xff, 0, 0, 0xff})
+				continue
+			}
+			l := uint8(0xff - (0xff-luma(a))/10)
+			diff.SetNRGBA(x, y, color.NRGBA{l, l, l, 0xff})
+		}
+	}
+	return r, diff
+}
+
+// blend composites c over a gray background, 0 for black and 0xffff for
+// white, returning 8 bit channels.
+func blend(c color.Color, bg float64) (float64, float64, float64) {
+	r, g, b, a := c.RGBA()
+	under := bg * float64(0xffff-a) / 0xffff
+	return (float64(r) + under) / 257, (float64(g) + under) / 257, (float64(b) + under) / 257
+}
+
+// colorDelta is the squared YIQ distance between two colors, as used by
+// pixelmatch. Colors are blended over both black and white and the larger
+// distance is returned, so that transparency differences are not hidden by
+// the background.
+func colorDelta(a, b color.Color) float64 {
+	return math.Max(blendedDelta(a, b, 0), blendedDelta(a, b, 0xffff))
+}
+
+func blendedDelta(a, b color.Color, bg float64) float64 {
+	r1, g1, b1 := blend(a, bg)
+	r2, g2, b2 := blend(b, bg)
+	y := rgb2y(r1, g1, b1) - rgb2y(r2, g2, b2)
+	i := rgb2i(r1, g1, b1) - rgb2i(r2, g2, b2)
+	q := rgb2q(r1, g1, b1) - rgb2q(r2, g2, b2)
+	return 0.5053*y*y + 0.299*i*i + 0.1957*q*q
+}
+
+func rgb2y(r, g, b float64) float64 { return r*0.29889531 + g*0.58662247 + b*0.11448223 }
+func rgb2i(r, g, b float64) float64 { return r*0.59597799 - g*0.27417610 - b*0.32180189 }
+func rgb2q(r, g, b float64) float64 { return r*0.21147017 - g*0.52261711 + b*0.31114694 }
+
+func luma(c color.Color) uint8 {
+	r, g, b := blend(c, 0xffff)
+	return uint8(math.Min(255, rgb2y(r, g, b)))
+}
+
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return img, nil
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func copyFile(src, dst string) error {
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, data, 0644)
+}
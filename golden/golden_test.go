@@ -0,0 +1,79 @@
+package golden
+
+import (
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func square(c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestCompare(t *testing.T) {
+	want := square(color.NRGBA{0x20, 0x40, 0x80, 0xff})
+
+	close := square(color.NRGBA{0x21, 0x41, 0x80, 0xff})
+	if r, _ := Compare(want, close, 0.1); r.Diff != 0 || r.Total != 100 {
+		t.Errorf("expected no differences, got %+v", r)
+	}
+
+	far := square(color.NRGBA{0x20, 0x40, 0x80, 0xff})
+	far.Set(3, 4, color.NRGBA{0xff, 0xff, 0, 0xff})
+	r, diff := Compare(want, far, 0.1)
+	if r.Diff != 1 {
+		t.Errorf("expected 1 difference, got %+v", r)
+	}
+	if c := diff.At(3, 4).(color.NRGBA); c != (color.NRGBA{0xff, 0, 0, 0xff}) {
+		t.Errorf("expected red diff pixel, got %v", c)
+	}
+
+	transparent := square(color.NRGBA{})
+	white := square(color.NRGBA{0xff, 0xff, 0xff, 0xff})
+	if r, _ := Compare(transparent, white, 0.1); r.Diff != r.Total {
+		t.Errorf("transparent and white should differ, got %+v", r)
+	}
+
+	if r, _ := Compare(want, image.NewNRGBA(image.Rect(0, 0, 5, 5)), 0.1); !r.SizeMismatch {
+		t.Error("expected size mismatch")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golden-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	want := filepath.Join(dir, "want.png")
+	got := filepath.Join(dir, "got.png")
+	if err := writePNG(want, square(color.Black)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writePNG(got, square(color.Black)); err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{DiffDir: filepath.Join(dir, "diffs")}
+	if err := Check("same", got, want, opts); err != nil {
+		t.Error(err)
+	}
+	if err := writePNG(got, square(color.White)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Check("changed", got, want, opts); err == nil {
+		t.Error("expected a difference")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "diffs", "changed.diff.png")); err != nil {
+		t.Error(err)
+	}
+}
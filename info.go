@@ -0,0 +1,48 @@
+package asset
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Summary counts the contents of a catalog.
+type Summary struct {
+	Groups int
+	// Sets counts sets by kind, such as imageset or appiconset. Sets nested
+	// in other sets, such as stickers, are included.
+	Sets map[string]int
+	// Files and Bytes count the files referenced by the sets.
+	Files int
+	Bytes int64
+}
+
+// Summarize counts the groups, sets and files of the catalog at dir.
+func Summarize(dir string) (*Summary, error) {
+	if _, err := NewCatalog(dir); err != nil {
+		return nil, err
+	}
+	s := &Summary{Sets: map[string]int{}}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() || path == dir {
+			return err
+		}
+		ext := filepath.Ext(path)
+		if ext == "" {
+			s.Groups++
+			return nil
+		}
+		s.Sets[ext[1:]]++
+		var contents setContents
+		if _, err := readContents(path, &contents); err != nil {
+			return err
+		}
+		for _, f := range contents.files() {
+			if stat, err := os.Stat(filepath.Join(path, f)); err == nil {
+				s.Files++
+				s.Bytes += stat.Size()
+			}
+		}
+		return nil
+	})
+	return s, err
+}
@@ -0,0 +1,41 @@
+package asset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "info-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{
+		"asset.yaml":     "scales: [1, 2]\n",
+		"a.svg":          testSVG,
+		"icons/lock.svg": testSVG,
+		"levels.json":    "{}",
+	})
+	catalog := newTestCatalog(t, tmpDir)
+	walker := &SVGWalker{Converter: &recordingConverter{}, Catalog: catalog, Data: []string{"*.json"}}
+	require.NoError(t, walker.Walk(src))
+	require.NoError(t, catalog.Write())
+
+	summary, err := Summarize(catalog.Dir)
+	require.NoError(t, err)
+	// Four PNGs of "png" and the two byte data file.
+	require.Equal(t, &Summary{
+		Groups: 1,
+		Sets:   map[string]int{"imageset": 2, "dataset": 1},
+		Files:  5,
+		Bytes:  14,
+	}, summary)
+
+	_, err = Summarize(src)
+	require.Error(t, err)
+}
@@ -0,0 +1,307 @@
+package asset
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LaunchImageOptions control how a logo SVG is turned into launch images.
+type LaunchImageOptions struct {
+	// Name of the launch image set or image set. Defaults to LaunchImage or
+	// LaunchBackground.
+	Name string
+	// Background fills the area around the logo. Defaults to white since
+	// launch images may not be transparent.
+	Background color.Color
+	// LogoWidth and LogoHeight are the size of the centered logo in points.
+	// They default to the size declared by the SVG.
+	LogoWidth, LogoHeight float32
+	// Idioms restricts the launch images to some device classes, "iphone"
+	// and "ipad". All are generated by default.
+	Idioms []string
+	// Size is the size in points of the storyboard background created by
+	// AddLaunchBackgroundSVG. Defaults to 414x896, the largest iPhone.
+	Size Size
+}
+
+type launchImageSpec struct {
+	idiom       string
+	orientation string
+	subtype     string
+	minVersion  string
+	scale       int
+	width       int
+	height      int
+}
+
+// launchImageSpecs lists every full screen launch image Xcode accepts for
+// iOS 7 and later. Sizes are in pixels.
+var launchImageSpecs = []launchImageSpec{
+	{"iphone", "portrait", "", "7.0", 2, 640, 960},
+	{"iphone", "portrait", "retina4", "7.0", 2, 640, 1136},
+	{"iphone", "portrait", "667h", "8.0", 2, 750, 1334},
+	{"iphone", "portrait", "736h", "8.0", 3, 1242, 2208},
+	{"iphone", "landscape", "736h", "8.0", 3, 2208, 1242},
+	{"iphone", "portrait", "2436h", "11.0", 3, 1125, 2436},
+	{"iphone", "landscape", "2436h", "11.0", 3, 2436, 1125},
+	{"iphone", "portrait", "2688h", "12.0", 3, 1242, 2688},
+	{"iphone", "landscape", "2688h", "12.0", 3, 2688, 1242},
+	{"iphone", "portrait", "1792h", "12.0", 2, 828, 1792},
+	{"iphone", "landscape", "1792h", "12.0", 2, 1792, 828},
+	{"ipad", "portrait", "", "7.0", 1, 768, 1024},
+	{"ipad", "portrait", "", "7.0", 2, 1536, 2048},
+	{"ipad", "landscape", "", "7.0", 1, 1024, 768},
+	{"ipad", "landscape", "", "7.0", 2, 2048, 1536},
+}
+
+func (o LaunchImageOptions) wants(idiom string) bool {
+	if len(o.Idioms) == 0 {
+		return true
+	}
+	for _, i := range o.Idioms {
+		if i == idiom {
+			return true
+		}
+	}
+	return false
+}
+
+func (o LaunchImageOptions) background() color.Color {
+	if o.Background == nil {
+		return color.White
+	}
+	return o.Background
+}
+
+// AddLaunchImageSVG renders the SVG at path centered on a background to every
+// launch image size of the selected device classes.
+func (s *SVGWalker) AddLaunchImageSVG(path string, opts LaunchImageOptions) error {
+	if !strings.HasSuffix(path, ".svg") {
+		return fmt.Errorf("%s: not an svg file", path)
+	}
+	if opts.Name == "" {
+		opts.Name = "LaunchImage"
+	}
+	launch := s.Catalog.LaunchImages[opts.Name]
+	if launch == nil {
+		var err error
+		if launch, err = NewLaunchImage(filepath.Join(s.Catalog.Dir, opts.Name+".launchimage")); err != nil {
+			return err
+		}
+		s.Catalog.LaunchImages[opts.Name] = launch
+	}
+	var specs []launchImageSpec
+	for _, spec := range launchImageSpecs {
+		if opts.wants(spec.idiom) {
+			specs = append(specs, spec)
+		}
+	}
+	stamp := opts.stamp()
+	files := make([]string, len(specs))
+	for i, spec := range specs {
+		name := []string{opts.Name, spec.idiom, spec.orientation}
+		if spec.subtype != "" {
+			name = append(name, spec.subtype)
+		}
+		files[i] = fmt.Sprintf("%s-%s-@%dx.png", strings.Join(name, "-"), stamp, spec.scale)
+	}
+	p, err := s.parseSVG(launch.Dir, sameFiles(launch.Images, files), path, len(specs))
+	if err != nil || !p.update {
+		return err
+	}
+	stale := staleFiles(launch.Images, files)
+	logoW, logoH := opts.logoSize(p)
+	launch.Images = make([]Image, len(specs))
+	for i, spec := range specs {
+		file := files[i]
+		launch.Images[i] = Image{
+			FileName:             file,
+			Idiom:                spec.idiom,
+			Orientation:          spec.orientation,
+			Extent:               "full-screen",
+			MinimumSystemVersion: spec.minVersion,
+			Subtype:              spec.subtype,
+			Scale:                fmt.Sprintf("%dx", spec.scale),
+			generator: s.launchGenerator(filepath.Join(launch.Dir, file), path, opts.background(),
+				spec.scale, logoW, logoH, spec.width, spec.height),
+		}
+	}
+	removeAfter(launch.Dir, launch.Images, stale)
+	return nil
+}
+
+// AddLaunchBackgroundSVG renders the SVG at path centered on a background
+// into a universal image set, for use as the background of a launch screen
+// storyboard.
+func (s *SVGWalker) AddLaunchBackgroundSVG(path string, opts LaunchImageOptions) error {
+	if !strings.HasSuffix(path, ".svg") {
+		return fmt.Errorf("%s: not an svg file", path)
+	}
+	if opts.Name == "" {
+		opts.Name = "LaunchBackground"
+	}
+	if opts.Size.Width == 0 || opts.Size.Height == 0 {
+		opts.Size = Size{Width: 414, Height: 896}
+	}
+	image, err := s.imageSet(s.Catalog.Container, opts.Name, path, path)
+	if err != nil {
+		return err
+	}
+	stamp := opts.stamp()
+	files := make([]string, 3)
+	for scale := 1; scale <= 3; scale++ {
+		files[scale-1] = fmt.Sprintf("%s-%s-%dx.png", opts.Name, stamp, scale)
+	}
+	p, err := s.parseSVG(image.Dir, sameFiles(image.Images, files), path, 3)
+	if err != nil || !p.update {
+		return err
+	}
+	stale := staleFiles(image.Images, files)
+	logoW, logoH := opts.logoSize(p)
+	image.Images = make([]Image, 3)
+	for scale := 1; scale <= 3; scale++ {
+		file := files[scale-1]
+		image.Images[scale-1] = Image{
+			FileName: file,
+			Idiom:    "universal",
+			Scale:    fmt.Sprintf("%dx", scale),
+			generator: s.launchGenerator(filepath.Join(image.Dir, file), path, opts.background(),
+				scale, logoW, logoH, int(opts.Size.Width)*scale, int(opts.Size.Height)*scale),
+		}
+	}
+	removeAfter(image.Dir, image.Images, stale)
+	return nil
+}
+
+// stamp is a short hash of the options that change the rendered images. It
+// is part of the file names so that changing the options re-renders them.
+func (o LaunchImageOptions) stamp() string {
+	r, g, b, a := o.background().RGBA()
+	sum := sha256.Sum256([]byte(fmt.Sprint(r, g, b, a, o.LogoWidth, o.LogoHeight, o.Size, o.Idioms)))
+	return hex.EncodeToString(sum[:4])
+}
+
+// sameFiles returns images if they are the given files, and otherwise nil
+// so that every file is rendered again.
+func sameFiles(images []Image, files []string) []Image {
+	if len(images) != len(files) {
+		return nil
+	}
+	for i, f := range files {
+		if images[i].FileName != f {
+			return nil
+		}
+	}
+	return images
+}
+
+// staleFiles returns the files of images that are not among files.
+func staleFiles(images []Image, files []string) []string {
+	keep := map[string]bool{}
+	for _, f := range files {
+		keep[f] = true
+	}
+	var stale []string
+	for _, i := range images {
+		if i.FileName != "" && !keep[i.FileName] {
+			stale = append(stale, i.FileName)
+		}
+	}
+	return stale
+}
+
+// removeAfter removes the stale files from dir once the last of images is
+// rendered, which is only after every earlier one rendered successfully.
+func removeAfter(dir string, images []Image, stale []string) {
+	if len(stale) == 0 || len(images) == 0 {
+		return
+	}
+	last := &images[len(images)-1]
+	render := last.generator
+	last.generator = func() error {
+		if err := render(); err != nil {
+			return err
+		}
+		for _, f := range stale {
+			Log("Removing", filepath.Join(dir, f))
+			if err := os.Remove(filepath.Join(dir, f)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (o LaunchImageOptions) logoSize(p parsedSVG) (float32, float32) {
+	w, h := o.LogoWidth, o.LogoHeight
+	switch {
+	case w == 0 && h == 0:
+		return p.width, p.height
+	case w == 0:
+		return h * p.width / p.height, h
+	case h == 0:
+		return w, w * p.height / p.width
+	}
+	return w, h
+}
+
+func (s *SVGWalker) launchGenerator(file, svg string, bg color.Color, scale int, logoW, logoH float32, width, height int) func() error {
+	return func() error {
+		Log("Generating", file)
+		start := time.Now()
+		tmp, err := ioutil.TempFile("", "logo")
+		if err != nil {
+			return err
+		}
+		tmp.Close()
+		defer os.Remove(tmp.Name())
+		if err := s.Converter.Convert(scale, logoH, logoW, svg, tmp.Name()); err != nil {
+			return err
+		}
+		logo, err := decodeImage(tmp.Name())
+		if err != nil {
+			return err
+		}
+		canvas := image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(canvas, canvas.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+		lb := logo.Bounds()
+		at := image.Pt((width-lb.Dx())/2, (height-lb.Dy())/2)
+		draw.Draw(canvas, lb.Sub(lb.Min).Add(at), logo, lb.Min, draw.Over)
+		if err := writePNG(file, canvas); err != nil {
+			return err
+		}
+		s.report.rendered(file, OutputGenerated, time.Since(start))
+		return nil
+	}
+}
+
+// ParseHexColor parses colors written as #rgb, #rrggbb or #rrggbbaa.
+func ParseHexColor(s string) (color.NRGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return color.NRGBA{}, errors.Errorf("%s: invalid color", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, errors.Errorf("%s: invalid color", s)
+	}
+	return color.NRGBA{uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
+}
@@ -0,0 +1,119 @@
+package asset
+
+import (
+	"encoding/json"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testLogoSVG = `<svg xmlns="http://www.w3.org/2000/svg" width="20" height="10"><rect width="20" height="10" fill="#ff0000"/></svg>`
+
+func TestSVGWalker_AddLaunchImageSVG(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "launch-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	logo := filepath.Join(tmpDir, "logo.svg")
+	require.NoError(t, ioutil.WriteFile(logo, []byte(testLogoSVG), 0600))
+
+	catalog := newTestCatalog(t, tmpDir)
+	walker := &SVGWalker{Converter: NativeConverter{}, Catalog: catalog}
+	require.NoError(t, walker.AddLaunchImageSVG(logo, LaunchImageOptions{
+		Background: color.NRGBA{0, 0, 0xff, 0xff},
+		Idioms:     []string{"ipad"},
+	}))
+	require.NoError(t, catalog.Write())
+
+	dir := filepath.Join(catalog.Dir, "LaunchImage.launchimage")
+	data, err := ioutil.ReadFile(filepath.Join(dir, "Contents.json"))
+	require.NoError(t, err)
+	var contents LaunchImage
+	require.NoError(t, json.Unmarshal(data, &contents))
+	require.Len(t, contents.Images, 4)
+	first := contents.Images[0]
+	require.Equal(t, "ipad", first.Idiom)
+	require.Equal(t, "portrait", first.Orientation)
+	require.Equal(t, "full-screen", first.Extent)
+	require.Equal(t, "7.0", first.MinimumSystemVersion)
+	require.Equal(t, "1x", first.Scale)
+
+	img, err := decodeImage(filepath.Join(dir, contents.Images[3].FileName))
+	require.NoError(t, err)
+	require.Equal(t, 2048, img.Bounds().Dx())
+	require.Equal(t, 1536, img.Bounds().Dy())
+	r, _, b, _ := img.At(0, 0).RGBA()
+	require.Equal(t, []uint32{0, 0xffff}, []uint32{r, b})
+	r, _, b, _ = img.At(1024, 768).RGBA()
+	require.Equal(t, []uint32{0xffff, 0}, []uint32{r, b})
+	// The 40x20 logo is centered.
+	r, _, _, _ = img.At(1024-21, 768).RGBA()
+	require.Equal(t, uint32(0), r)
+}
+
+func TestSVGWalker_AddLaunchBackgroundSVG(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "launch-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	logo := filepath.Join(tmpDir, "logo.svg")
+	require.NoError(t, ioutil.WriteFile(logo, []byte(testLogoSVG), 0600))
+
+	catalog := newTestCatalog(t, tmpDir)
+	walker := &SVGWalker{Converter: NativeConverter{}, Catalog: catalog}
+	require.NoError(t, walker.AddLaunchBackgroundSVG(logo, LaunchImageOptions{Size: Size{Width: 100, Height: 50}}))
+	require.NoError(t, catalog.Write())
+
+	bg := catalog.Images["LaunchBackground"]
+	require.NotNil(t, bg)
+	require.Len(t, bg.Images, 3)
+	w, h := pngSize(t, filepath.Join(bg.Dir, bg.Images[2].FileName))
+	require.Equal(t, []int{300, 150}, []int{w, h})
+
+	// Unchanged options leave the images alone, changed ones re-render them
+	// and remove the old files.
+	add := func(conv SVGConverter, opts LaunchImageOptions) *ImageSet {
+		catalog := newTestCatalog(t, tmpDir)
+		walker := &SVGWalker{Converter: conv, Catalog: catalog}
+		require.NoError(t, walker.AddLaunchBackgroundSVG(logo, opts))
+		return catalog.Images["LaunchBackground"]
+	}
+	bg = add(NativeConverter{}, LaunchImageOptions{Size: Size{Width: 100, Height: 50}})
+	require.Nil(t, bg.Images[0].generator)
+	old := filepath.Join(bg.Dir, bg.Images[0].FileName)
+	black := LaunchImageOptions{Size: Size{Width: 100, Height: 50}, Background: color.Black}
+
+	// The old files are kept until the new ones are rendered. The recording
+	// converter does not write a PNG, so rendering fails.
+	bg = add(&recordingConverter{}, black)
+	require.NotNil(t, bg.Images[0].generator)
+	require.Error(t, bg.Write())
+	_, err = os.Stat(old)
+	require.NoError(t, err)
+
+	bg = add(NativeConverter{}, black)
+	require.NoError(t, bg.Write())
+	_, err = os.Stat(old)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestParseHexColor(t *testing.T) {
+	for s, expected := range map[string]color.NRGBA{
+		"#fff":      {0xff, 0xff, 0xff, 0xff},
+		"#102030":   {0x10, 0x20, 0x30, 0xff},
+		"10203040":  {0x10, 0x20, 0x30, 0x40},
+		"#ABCDEF80": {0xab, 0xcd, 0xef, 0x80},
+	} {
+		c, err := ParseHexColor(s)
+		require.NoError(t, err, s)
+		require.Equal(t, expected, c, s)
+	}
+	for _, s := range []string{"", "#12", "#gggggg"} {
+		_, err := ParseHexColor(s)
+		require.Error(t, err, s)
+	}
+}
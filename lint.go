@@ -0,0 +1,190 @@
+package asset
+
+import (
+	"fmt"
+	"image"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LintProblem is a problem found by LintCatalog.
+type LintProblem struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (p LintProblem) String() string { return p.Path + ": " + p.Message }
+
+// setContents holds the references to files and folders in the
+// Contents.json of any kind of set.
+type setContents struct {
+	Images     []Image   `json:"images"`
+	Data       []fileRef `json:"data"`
+	Symbols    []fileRef `json:"symbols"`
+	Properties fileRef   `json:"properties"`
+	Stickers   []fileRef `json:"stickers"`
+	Layers     []fileRef `json:"layers"`
+	Assets     []fileRef `json:"assets"`
+}
+
+type fileRef struct {
+	FileName string `json:"filename"`
+}
+
+// files returns the names of the files referenced by c.
+func (c *setContents) files() []string {
+	var files []string
+	for _, i := range c.Images {
+		files = append(files, i.FileName)
+	}
+	for _, refs := range [][]fileRef{c.Data, c.Symbols, {c.Properties}} {
+		for _, r := range refs {
+			files = append(files, r.FileName)
+		}
+	}
+	return nonEmpty(files)
+}
+
+// folders returns the names of the sets nested in the set of c.
+func (c *setContents) folders() []string {
+	var folders []string
+	for _, refs := range [][]fileRef{c.Stickers, c.Layers, c.Assets} {
+		for _, r := range refs {
+			folders = append(folders, r.FileName)
+		}
+	}
+	return nonEmpty(folders)
+}
+
+func nonEmpty(names []string) []string {
+	var out []string
+	for _, n := range names {
+		if n != "" {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// LintCatalog checks every set in the catalog at dir for a missing
+// Contents.json, missing or unreferenced files and images whose pixel size
+// does not match their size in points at their scale.
+func LintCatalog(dir string) ([]LintProblem, error) {
+	if _, err := NewCatalog(dir); err != nil {
+		return nil, err
+	}
+	var problems []LintProblem
+	report := func(path, format string, args ...interface{}) {
+		problems = append(problems, LintProblem{path, fmt.Sprintf(format, args...)})
+	}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() || path == dir {
+			return err
+		}
+		var contents setContents
+		ok, err := readContents(path, &contents)
+		if err != nil {
+			report(path, "%v", err)
+			return nil
+		}
+		if !ok {
+			report(path, "missing Contents.json")
+			return nil
+		}
+		if filepath.Ext(path) == "" {
+			return nil
+		}
+		referenced := map[string]bool{"Contents.json": true}
+		for _, f := range contents.files() {
+			referenced[f] = true
+			if stat, err := os.Stat(filepath.Join(path, f)); err != nil || stat.IsDir() {
+				report(path, "missing file %s", f)
+			}
+		}
+		for _, f := range contents.folders() {
+			referenced[f] = true
+			if stat, err := os.Stat(filepath.Join(path, f)); err != nil || !stat.IsDir() {
+				report(path, "missing folder %s", f)
+			}
+		}
+		entries, err := ioutil.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if !e.IsDir() && !referenced[e.Name()] {
+				report(path, "unreferenced file %s", e.Name())
+			}
+		}
+		for _, msg := range lintScales(path, contents.Images) {
+			report(path, "%s", msg)
+		}
+		return nil
+	})
+	return problems, err
+}
+
+// lintScales checks that images of the same variant at different scales
+// have the same size in points, and that images with a size, such as app
+// icons, have as many pixels as their size at their scale.
+func lintScales(dir string, images []Image) []string {
+	type points struct {
+		file          string
+		width, height float64
+	}
+	var msgs []string
+	variants := map[string]points{}
+	for _, i := range images {
+		scale, err := strconv.ParseFloat(strings.TrimSuffix(i.Scale, "x"), 64)
+		if i.FileName == "" || err != nil || scale <= 0 {
+			continue
+		}
+		w, h, ok := imageSize(filepath.Join(dir, i.FileName))
+		if !ok {
+			continue
+		}
+		if i.Size != "" {
+			var sw, sh float64
+			if _, err := fmt.Sscanf(i.Size, "%gx%g", &sw, &sh); err == nil &&
+				(math.Abs(sw*scale-float64(w)) >= 1 || math.Abs(sh*scale-float64(h)) >= 1) {
+				msgs = append(msgs, fmt.Sprintf("%s is %dx%d px, expected %gx%g px for %s@%s",
+					i.FileName, w, h, sw*scale, sh*scale, i.Size, i.Scale))
+			}
+			continue
+		}
+		p := points{i.FileName, float64(w) / scale, float64(h) / scale}
+		variant := i
+		variant.FileName, variant.Scale = "", ""
+		attrs, _ := imageAttrs(variant)
+		key := fmt.Sprint(attrs)
+		first, seen := variants[key]
+		if !seen {
+			variants[key] = p
+			continue
+		}
+		if math.Abs(first.width-p.width) >= 1 || math.Abs(first.height-p.height) >= 1 {
+			msgs = append(msgs, fmt.Sprintf("%s is %gx%g pt, but %s is %gx%g pt",
+				p.file, p.width, p.height, first.file, first.width, first.height))
+		}
+	}
+	sort.Strings(msgs)
+	return msgs
+}
+
+func imageSize(path string) (int, int, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, false
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, false
+	}
+	return cfg.Width, cfg.Height, true
+}
@@ -0,0 +1,49 @@
+package asset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintCatalog(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "lint-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{
+		"asset.yaml": "scales: [1, 2]\n",
+		"a.svg":      testSVG,
+		"b.svg":      testSVG,
+	})
+	catalog := newTestCatalog(t, tmpDir)
+	walker := &SVGWalker{Converter: NativeConverter{}, Catalog: catalog}
+	require.NoError(t, walker.Walk(src))
+	require.NoError(t, catalog.Write())
+
+	problems, err := LintCatalog(catalog.Dir)
+	require.NoError(t, err)
+	require.Empty(t, problems)
+
+	a := filepath.Join(catalog.Dir, "a.imageset")
+	b := filepath.Join(catalog.Dir, "b.imageset")
+	data, err := ioutil.ReadFile(filepath.Join(a, "a-1x.png"))
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(a, "a-2x.png"), data, 0600))
+	require.NoError(t, os.Remove(filepath.Join(b, "b-1x.png")))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(b, "stray.png"), data, 0600))
+	require.NoError(t, os.Mkdir(filepath.Join(catalog.Dir, "empty"), 0700))
+
+	problems, err = LintCatalog(catalog.Dir)
+	require.NoError(t, err)
+	require.Equal(t, []LintProblem{
+		{a, "a-2x.png is 15x20 pt, but a-1x.png is 30x40 pt"},
+		{b, "missing file b-1x.png"},
+		{b, "unreferenced file stray.png"},
+		{filepath.Join(catalog.Dir, "empty"), "missing Contents.json"},
+	}, problems)
+}
@@ -0,0 +1,171 @@
+package asset
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Language directions of an image entry.
+const (
+	LeftToRight = "left-to-right"
+	RightToLeft = "right-to-left"
+)
+
+// localeOf reports whether folder holds localized sources rather than a
+// group, returning its locale. Folders named like fr.lproj are always
+// locale folders, with Base.lproj holding unlocalized sources. Other folders
+// are locale folders if listed in the locales config.
+func (p *settings) localeOf(folder string) (string, bool) {
+	if strings.HasSuffix(folder, ".lproj") {
+		locale := strings.TrimSuffix(folder, ".lproj")
+		if locale == "Base" {
+			return "", true
+		}
+		return strings.Replace(locale, "_", "-", -1), true
+	}
+	for _, l := range p.locales {
+		if l == folder {
+			return strings.Replace(folder, "_", "-", -1), true
+		}
+	}
+	return "", false
+}
+
+// mirrorRequest is an SVG whose right-to-left variant is generated by
+// mirroring it, unless another source provides one.
+type mirrorRequest struct {
+	image  *ImageSet
+	target string
+	v      variant
+	path   string
+	file   string
+	cfg    *settings
+	t      svgTransform
+}
+
+// addMirrored adds the right-to-left entries for m.
+func (s *SVGWalker) addMirrored(m mirrorRequest) error {
+	rtl := m.v
+	rtl.direction = RightToLeft
+	rtl.name = strings.Trim(rtl.name+VariantSeparator+"rtl", VariantSeparator)
+	if _, ok := s.variants[m.image][rtl.slot()]; ok {
+		return nil
+	}
+	t := m.t
+	t.mirror = true
+	return s.addSVGVariant(m.image, m.target, rtl, m.path, m.file, m.cfg, t)
+}
+
+// Finish runs the steps that need every source of the walk: mirroring,
+// building symbol templates, dropping entries of removed sources and
+// setting the properties that depend on all entries of an image set. Walk
+// calls it, callers of AddPath must call it once every path is added. See
+// Reset.
+func (s *SVGWalker) Finish() error {
+	defer func() {
+		// Configs are read again and pending work is not repeated for paths
+		// added after this.
+		s.settings, s.mirrors, s.symbols = nil, nil, nil
+	}()
+	for _, m := range s.mirrors {
+		if err := s.addMirrored(m); err != nil {
+			return err
+		}
+	}
+	if err := s.finishSymbols(); err != nil {
+		return err
+	}
+	s.pruneVariants()
+	s.pruneData()
+	for image := range s.variants {
+		rtl, localized := false, false
+		for _, i := range image.Images {
+			rtl = rtl || i.LanguageDirection == RightToLeft
+			localized = localized || i.Locale != ""
+		}
+		for j, i := range image.Images {
+			// Unqualified entries are left-to-right when the image set has
+			// right-to-left entries, and only otherwise when a ~ltr source
+			// asks for them to be mirrored at runtime.
+			direction := s.variants[image][slotOf(i)].direction
+			if direction == "" && rtl {
+				direction = LeftToRight
+			}
+			image.Images[j].LanguageDirection = direction
+		}
+		image.Properties.Localizable = localized
+	}
+	return nil
+}
+
+// mirrorSVG wraps the contents of the root svg element in a group that
+// flips it around the vertical center of its view box.
+func mirrorSVG(data []byte) ([]byte, error) {
+	root, open, end, err := svgRoot(data)
+	if err != nil {
+		return nil, err
+	}
+	if open == end {
+		return data, nil
+	}
+	axis, err := mirrorAxis(root.Attr)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Write(data[:open])
+	fmt.Fprintf(&buf, `<g transform="matrix(-1 0 0 1 %g 0)">`, axis)
+	buf.Write(data[open:end])
+	buf.WriteString("</g>")
+	buf.Write(data[end:])
+	return buf.Bytes(), nil
+}
+
+// mirrorAxis returns twice the x coordinate of the center of the root view
+// box, falling back to the width when there is no view box.
+func mirrorAxis(attrs []xml.Attr) (float64, error) {
+	var width string
+	for _, a := range attrs {
+		switch a.Name.Local {
+		case "viewBox":
+			f := strings.FieldsFunc(a.Value, func(r rune) bool { return r == ',' || r == ' ' })
+			if len(f) != 4 {
+				return 0, errors.Errorf("invalid viewBox %q", a.Value)
+			}
+			minX, err := strconv.ParseFloat(f[0], 64)
+			if err != nil {
+				return 0, errors.Wrap(err, "invalid viewBox")
+			}
+			w, err := strconv.ParseFloat(f[2], 64)
+			if err != nil {
+				return 0, errors.Wrap(err, "invalid viewBox")
+			}
+			return 2*minX + w, nil
+		case "width":
+			width = a.Value
+		}
+	}
+	w, err := parseDim(width)
+	return float64(w), err
+}
+
+// mirrored returns the slicing of a horizontally mirrored image.
+func (s *Slice) mirrored() *Slice {
+	if s == nil {
+		return nil
+	}
+	m := *s
+	for _, i := range []**Insets{&m.CapInsets, &m.AlignmentInsets} {
+		if *i != nil {
+			flipped := **i
+			flipped.Left, flipped.Right = flipped.Right, flipped.Left
+			*i = &flipped
+		}
+	}
+	return &m
+}
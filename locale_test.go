@@ -0,0 +1,159 @@
+package asset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMirrorSVG(t *testing.T) {
+	for _, c := range []struct {
+		svg, expected string
+	}{
+		{
+			`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg" width="30"><path d="M0 0"/></svg>`,
+			`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg" width="30"><g transform="matrix(-1 0 0 1 30 0)"><path d="M0 0"/></g></svg>`,
+		},
+		{
+			`<svg viewBox="5 0 20 10" width="40"><rect/></svg>`,
+			`<svg viewBox="5 0 20 10" width="40"><g transform="matrix(-1 0 0 1 30 0)"><rect/></g></svg>`,
+		},
+		{`<svg width="10"/>`, `<svg width="10"/>`},
+	} {
+		out, err := mirrorSVG([]byte(c.svg))
+		require.NoError(t, err, c.svg)
+		require.Equal(t, c.expected, string(out))
+	}
+}
+
+func TestSVGWalker_LocaleAndDirection(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "locale-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	half := `<svg xmlns="http://www.w3.org/2000/svg" width="4" height="2"><rect width="2" height="2" fill="#f00"/></svg>`
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{
+		"asset.yaml":           "scales: [1]\nlocales: [de]\n",
+		"arrow.svg":            testSVG,
+		"arrow~rtl.svg":        testSVG,
+		"logo.svg":             testSVG,
+		"fr.lproj/logo.svg":    testSVG,
+		"de/logo.svg":          testSVG,
+		"fixed.svg":            testSVG,
+		"back/asset.yaml":      "mirror-rtl: true\n",
+		"back/back.svg":        half,
+		"back/runtime~ltr.svg": testSVG,
+	})
+
+	catalog := newTestCatalog(t, tmpDir)
+	walker := &SVGWalker{Converter: NativeConverter{}, Catalog: catalog}
+	require.NoError(t, walker.Walk(src))
+	require.NoError(t, catalog.Write())
+
+	type entry struct{ file, locale, direction string }
+	entries := func(i *ImageSet) []entry {
+		var e []entry
+		for _, img := range i.Images {
+			e = append(e, entry{img.FileName, img.Locale, img.LanguageDirection})
+		}
+		return e
+	}
+	require.Equal(t, []entry{
+		{"arrow-1x.png", "", LeftToRight},
+		{"arrow~rtl-1x.png", "", RightToLeft},
+	}, entries(catalog.Images["arrow"]))
+	require.False(t, catalog.Images["arrow"].Properties.Localizable)
+
+	logo := catalog.Images["logo"]
+	require.Equal(t, []entry{
+		{"logo~de-1x.png", "de", ""},
+		{"logo~fr-1x.png", "fr", ""},
+		{"logo-1x.png", "", ""},
+	}, entries(logo))
+	require.True(t, logo.Properties.Localizable)
+	require.Nil(t, catalog.Groups["de"])
+	require.Nil(t, catalog.Groups["fr.lproj"])
+
+	require.Equal(t, []entry{{"fixed-1x.png", "", ""}}, entries(catalog.Images["fixed"]))
+
+	back := catalog.Groups["back"]
+	require.Equal(t, []entry{
+		{"back-1x.png", "", LeftToRight},
+		{"back~rtl-1x.png", "", RightToLeft},
+	}, entries(back.Images["back"]))
+	// Sources qualified ~ltr are mirrored at runtime instead.
+	require.Equal(t, []entry{{"runtime~ltr-1x.png", "", LeftToRight}}, entries(back.Images["runtime"]))
+
+	img, err := decodeImage(filepath.Join(back.Images["back"].Dir, "back~rtl-1x.png"))
+	require.NoError(t, err)
+	_, _, _, left := img.At(0, 1).RGBA()
+	_, _, _, right := img.At(3, 1).RGBA()
+	require.Equal(t, []uint32{0, 0xffff}, []uint32{left, right})
+
+	// A second walk keeps the same entries.
+	catalog = newTestCatalog(t, tmpDir)
+	walker = &SVGWalker{Converter: NativeConverter{}, Catalog: catalog}
+	require.NoError(t, walker.Walk(src))
+	require.Equal(t, []entry{
+		{"back-1x.png", "", LeftToRight},
+		{"back~rtl-1x.png", "", RightToLeft},
+	}, entries(catalog.Groups["back"].Images["back"]))
+	require.Len(t, catalog.Images["logo"].Images, 3)
+}
+
+func TestSVGWalker_AddPath(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "addpath-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{
+		"asset.yaml":      "symbols: ['bell.svg']\nmirror-rtl: true\n",
+		"bell.svg":        glyphSVG,
+		"arrow.svg":       testSVG,
+		"icons/arrow.svg": testSVG,
+	})
+
+	catalog := newTestCatalog(t, tmpDir)
+	walker := &SVGWalker{Converter: &recordingConverter{}, Catalog: catalog}
+	walker.Reset()
+	for _, file := range []string{"bell.svg", "arrow.svg"} {
+		path := filepath.Join(src, file)
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.NoError(t, walker.AddPath(src, path, info))
+	}
+	require.NoError(t, walker.Finish())
+	require.NoError(t, catalog.Write())
+
+	require.NotNil(t, catalog.SymbolSets["bell"])
+	require.Len(t, catalog.SymbolSets["bell"].Symbols, 1)
+	_, err = os.Stat(filepath.Join(catalog.Dir, "bell.symbolset", "bell.svg"))
+	require.NoError(t, err)
+	var directions []string
+	for _, i := range catalog.Images["arrow"].Images {
+		directions = append(directions, i.LanguageDirection)
+	}
+	require.Contains(t, directions, RightToLeft)
+
+	// Configs are read again after Finish.
+	writeTree(t, src, map[string]string{"asset.yaml": "scales: [1]\n"})
+	path := filepath.Join(src, "icons", "arrow.svg")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, walker.AddPath(src, path, info))
+	require.NoError(t, walker.Finish())
+	require.Len(t, catalog.Groups["icons"].Images["arrow"].Images, 1)
+
+	// A source renamed only in case clashes with its old name until Reset.
+	require.NoError(t, os.Rename(path, filepath.Join(src, "icons", "Arrow.svg")))
+	path = filepath.Join(src, "icons", "Arrow.svg")
+	require.Error(t, walker.AddPath(src, path, info))
+	walker.Reset()
+	require.NoError(t, walker.AddPath(src, path, info))
+	require.NoError(t, walker.Finish())
+}
@@ -0,0 +1,171 @@
+package asset
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// A Sanitizer rewrites a source file or folder name before it is used to
+// name a group, image set or generated file.
+type Sanitizer func(string) string
+
+// Sanitizers holds the sanitizers that can be referred to by name from
+// ParseSanitizer, config files and the command line. Register additional
+// ones here before walking.
+var Sanitizers = map[string]Sanitizer{
+	"spaces":        func(s string) string { return strings.Replace(s, " ", "_", -1) },
+	"ascii":         stripNonASCII,
+	"transliterate": transliterate,
+	"lower":         strings.ToLower,
+	"kebab":         func(s string) string { return strings.ToLower(strings.Join(words(s), "-")) },
+	"snake":         func(s string) string { return strings.ToLower(strings.Join(words(s), "_")) },
+	"camel":         camelCase,
+}
+
+// ParseSanitizer returns a Sanitizer applying the comma separated list of
+// named sanitizers in order, e.g. "transliterate,ascii,kebab".
+func ParseSanitizer(spec string) (Sanitizer, error) {
+	var chain []Sanitizer
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		s := Sanitizers[name]
+		if s == nil {
+			return nil, errors.Errorf("unknown sanitizer %q", name)
+		}
+		chain = append(chain, s)
+	}
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	return func(v string) string {
+		for _, s := range chain {
+			v = s(v)
+		}
+		return v
+	}, nil
+}
+
+func stripNonASCII(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+var transliterations = strings.NewReplacer(
+	"ß", "ss", "æ", "ae", "Æ", "AE", "œ", "oe", "Œ", "OE",
+	"ø", "o", "Ø", "O", "ł", "l", "Ł", "L", "đ", "d", "Đ", "D", "þ", "th", "Þ", "TH",
+)
+
+func transliterate(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	out, _, err := transform.String(t, transliterations.Replace(s))
+	if err != nil {
+		return s
+	}
+	return out
+}
+
+// words splits s at separators and lower to upper case transitions.
+func words(s string) []string {
+	var (
+		list []string
+		cur  []rune
+		prev rune
+	)
+	flush := func() {
+		if len(cur) > 0 {
+			list = append(list, string(cur))
+			cur = cur[:0]
+		}
+	}
+	for _, r := range s {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			flush()
+			cur = append(cur, r)
+		default:
+			cur = append(cur, r)
+		}
+		prev = r
+	}
+	flush()
+	return list
+}
+
+func camelCase(s string) string {
+	var buf bytes.Buffer
+	for i, w := range words(s) {
+		w = strings.ToLower(w)
+		if i > 0 {
+			r := []rune(w)
+			r[0] = unicode.ToUpper(r[0])
+			w = string(r)
+		}
+		buf.WriteString(w)
+	}
+	return buf.String()
+}
+
+// NameData is passed to the naming templates.
+type NameData struct {
+	// Name is the sanitized source name, or for file names, the image set name.
+	Name  string
+	Scale int
+	Idiom string
+	// Variant is the qualifier text of a device or size class variant, such
+	// as "ipad" for hero~ipad.svg. It is empty for other sources.
+	Variant string
+	// Size is the point size of an app icon image.
+	Size float32
+}
+
+const (
+	DefaultImageSetName    = "{{.Name}}"
+	DefaultFileName        = "{{.Name}}{{with .Variant}}~{{.}}{{end}}-{{.Scale}}x.png"
+	DefaultAppIconFileName = "{{.Name}}-{{.Idiom}}-@{{.Scale}}-{{int .Size}}.png"
+)
+
+var nameFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"int":   func(f float32) int { return int(f) },
+}
+
+func parseNameTemplate(name, text, def string) (*template.Template, error) {
+	if text == "" {
+		text = def
+	}
+	t, err := template.New(name).Funcs(nameFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s template", name)
+	}
+	return t, nil
+}
+
+func executeName(t *template.Template, d NameData) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, d); err != nil {
+		return "", errors.Wrapf(err, "failed to name %s", d.Name)
+	}
+	name := buf.String()
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("%s: template %s produced invalid name %q", d.Name, t.Name(), name)
+	}
+	return name, nil
+}
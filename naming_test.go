@@ -0,0 +1,71 @@
+package asset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSanitizer(t *testing.T) {
+	for _, c := range []struct {
+		spec, in, out string
+	}{
+		{"spaces", "foo bar baz", "foo_bar_baz"},
+		{"lower", "Foo Bar", "foo bar"},
+		{"kebab", "Foo barBaz_qux 2x", "foo-bar-baz-qux-2x"},
+		{"snake", "fooBar-Baz", "foo_bar_baz"},
+		{"camel", "foo bar-baz", "fooBarBaz"},
+		{"ascii", "café ☕", "caf "},
+		{"transliterate", "Crème brûlée straße", "Creme brulee strasse"},
+		{"transliterate,kebab", "Crème Brûlée", "creme-brulee"},
+	} {
+		s, err := ParseSanitizer(c.spec)
+		require.NoError(t, err)
+		require.Equal(t, c.out, s(c.in), "%+v", c)
+	}
+	s, err := ParseSanitizer("")
+	require.NoError(t, err)
+	require.Nil(t, s)
+	_, err = ParseSanitizer("lower,nope")
+	require.Error(t, err)
+}
+
+func TestSVGWalker_Naming(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "naming-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{
+		"Big Folder/Lock Icon.svg": testSVG,
+		"other/asset.yaml":         "sanitizer: camel\nfile-name: '{{.Name}}@{{.Scale}}x.png'\n",
+		"other/Home Icon.svg":      testSVG,
+	})
+	catalog := newTestCatalog(t, tmpDir)
+	sanitizer, err := ParseSanitizer("kebab")
+	require.NoError(t, err)
+	walker := &SVGWalker{
+		Converter:    &recordingConverter{},
+		Catalog:      catalog,
+		Sanitizer:    sanitizer,
+		ImageSetName: "ic-{{.Name}}",
+	}
+	require.NoError(t, walker.Walk(src))
+
+	lock := catalog.Groups["big-folder"].Images["ic-lock-icon"]
+	require.NotNil(t, lock)
+	require.Equal(t, "ic-lock-icon-2x.png", lock.Images[1].FileName)
+
+	home := catalog.Groups["other"].Images["ic-homeIcon"]
+	require.NotNil(t, home)
+	require.Equal(t, "ic-homeIcon@3x.png", home.Images[2].FileName)
+
+	require.NoError(t, walker.AddAppIconSVG(filepath.Join(src, "Big Folder/Lock Icon.svg")))
+	require.Equal(t, "lock-icon-ipad-@2-83.png", catalog.AppIcon.Images[16].FileName)
+
+	walker.FileName = "{{.Nope}}"
+	require.Error(t, walker.Walk(src))
+}
@@ -0,0 +1,213 @@
+package asset
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// PNGSavings records the effect of post-processing on the PNGs of one image
+// set.
+type PNGSavings struct {
+	ImageSet string
+	Files    int
+	Before   int64
+	After    int64
+}
+
+func (p PNGSavings) Saved() int64 {
+	return p.Before - p.After
+}
+
+type savingsLog struct {
+	sync.Mutex
+	byImageSet map[string]*PNGSavings
+}
+
+func (l *savingsLog) add(imageSet string, before, after int64) {
+	l.Lock()
+	defer l.Unlock()
+	if l.byImageSet == nil {
+		l.byImageSet = map[string]*PNGSavings{}
+	}
+	s := l.byImageSet[imageSet]
+	if s == nil {
+		s = &PNGSavings{ImageSet: imageSet}
+		l.byImageSet[imageSet] = s
+	}
+	s.Files++
+	s.Before += before
+	s.After += after
+}
+
+// Savings returns the bytes saved by Optimize and Quantize for every image
+// set written so far, sorted by image set, along with the total.
+func (s *SVGWalker) Savings() ([]PNGSavings, PNGSavings) {
+	s.savings.Lock()
+	defer s.savings.Unlock()
+	total := PNGSavings{}
+	list := make([]PNGSavings, 0, len(s.savings.byImageSet))
+	for _, v := range s.savings.byImageSet {
+		list = append(list, *v)
+		total.Files += v.Files
+		total.Before += v.Before
+		total.After += v.After
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ImageSet < list[j].ImageSet })
+	return list, total
+}
+
+// postOptions selects the passes run over each generated PNG.
+type postOptions struct {
+	optimize bool
+	quantize bool
+	maxError float64
+}
+
+// postOptions returns the passes for the source file, relative to the walk
+// root. cfg may be nil for sources outside a walk, such as app icons.
+func (s *SVGWalker) postOptions(cfg *settings, file string) postOptions {
+	p := postOptions{optimize: s.Optimize}
+	if cfg == nil {
+		return p
+	}
+	p.quantize, p.maxError = cfg.quantize, cfg.quantizeMaxError
+	for _, g := range s.Quantize {
+		if (globRule{".", g}).match(file) {
+			p.quantize = true
+		}
+	}
+	if p.maxError == 0 {
+		p.maxError = s.QuantizeMaxError
+	}
+	if p.maxError == 0 {
+		p.maxError = DefaultQuantizeMaxError
+	}
+	return p
+}
+
+// postProcess runs the optional passes over a freshly generated PNG.
+func (s *SVGWalker) postProcess(i *ImageSet, file string, p postOptions) error {
+	if !p.optimize && !p.quantize {
+		return nil
+	}
+	stat, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	before := stat.Size()
+	if p.quantize {
+		replaced, rms, err := QuantizePNG(file, p.maxError)
+		if err != nil {
+			return err
+		}
+		if !replaced {
+			s.report.warn(file, fmt.Sprintf("kept unquantized, error %.2f exceeds %.2f", rms, p.maxError))
+		}
+	}
+	if p.optimize {
+		if _, _, err := OptimizePNG(file); err != nil {
+			return err
+		}
+	}
+	if stat, err = os.Stat(file); err != nil {
+		return err
+	}
+	s.savings.add(i.Dir, before, stat.Size())
+	return nil
+}
+
+// OptimizePNG losslessly re-encodes the PNG at path with the best
+// compression, dropping ancillary chunks and reducing it to grayscale or a
+// palette when no information is lost. The file is only replaced if the
+// result is smaller. It returns the sizes before and after.
+func OptimizePNG(path string) (int64, int64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "%s: failed to decode png", path)
+	}
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(&buf, reduce(img)); err != nil {
+		return 0, 0, errors.Wrapf(err, "%s: failed to encode png", path)
+	}
+	before := int64(len(data))
+	if int64(buf.Len()) >= before {
+		return before, before, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), info.Mode()); err != nil {
+		return 0, 0, err
+	}
+	return before, int64(buf.Len()), nil
+}
+
+// reduce returns the smallest lossless representation of img that the png
+// encoder supports.
+func reduce(img image.Image) image.Image {
+	b := img.Bounds()
+	gray, opaque := true, true
+	colors := map[color.NRGBA]uint8{}
+	palette := color.Palette{}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A != 0xff {
+				opaque = false
+			}
+			if c.R != c.G || c.G != c.B {
+				gray = false
+			}
+			if _, ok := colors[c]; !ok && palette != nil {
+				if len(palette) == 256 {
+					palette = nil
+				} else {
+					colors[c] = uint8(len(palette))
+					palette = append(palette, c)
+				}
+			}
+		}
+	}
+	switch {
+	case gray && opaque && !is16Bit(img):
+		g := image.NewGray(b)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				g.Set(x, y, img.At(x, y))
+			}
+		}
+		return g
+	case palette != nil && !is16Bit(img):
+		p := image.NewPaletted(b, palette)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				p.SetColorIndex(x, y, colors[color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)])
+			}
+		}
+		return p
+	}
+	return img
+}
+
+func is16Bit(img image.Image) bool {
+	switch img.(type) {
+	case *image.RGBA64, *image.NRGBA64, *image.Gray16:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package asset
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func encodeUncompressed(t *testing.T, path string, img image.Image) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, (&png.Encoder{CompressionLevel: png.NoCompression}).Encode(f, img))
+	require.NoError(t, f.Close())
+}
+
+func requireSamePixels(t *testing.T, want image.Image, path string) {
+	got, err := decodeImage(path)
+	require.NoError(t, err)
+	require.Equal(t, want.Bounds(), got.Bounds())
+	b := want.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			require.Equal(t,
+				color.RGBA64Model.Convert(want.At(x, y)),
+				color.RGBA64Model.Convert(got.At(x, y)), "pixel %d,%d", x, y)
+		}
+	}
+}
+
+func TestOptimizePNG(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "optimize-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	gray := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	palette := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	full := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			gray.Set(x, y, color.NRGBA{uint8(x * 4), uint8(x * 4), uint8(x * 4), 0xff})
+			palette.Set(x, y, color.NRGBA{uint8(x % 3 * 80), 0x10, 0x20, uint8(y % 2 * 0x80)})
+			full.Set(x, y, color.NRGBA{uint8(x * 4), uint8(y * 4), uint8(x + y), uint8(0xff - x)})
+		}
+	}
+	for name, img := range map[string]image.Image{"gray": gray, "palette": palette, "full": full} {
+		path := filepath.Join(tmpDir, name+".png")
+		encodeUncompressed(t, path, img)
+		before, after, err := OptimizePNG(path)
+		require.NoError(t, err)
+		require.True(t, after < before, "%s: %d -> %d", name, before, after)
+		stat, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, after, stat.Size())
+		requireSamePixels(t, img, path)
+	}
+
+	optimized := filepath.Join(tmpDir, "full.png")
+	before, after, err := OptimizePNG(optimized)
+	require.NoError(t, err)
+	require.Equal(t, before, after)
+}
+
+func TestSVGWalker_Optimize(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "optimize-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	img := image.NewNRGBA(image.Rect(0, 0, 30, 30))
+	require.NoError(t, os.MkdirAll(src, 0700))
+	encodeUncompressed(t, filepath.Join(src, "flat@3x.png"), img)
+
+	catalog := newTestCatalog(t, tmpDir)
+	walker := &SVGWalker{Catalog: catalog, Optimize: true}
+	require.NoError(t, walker.Walk(src))
+	require.NoError(t, catalog.Write())
+
+	savings, total := walker.Savings()
+	require.Len(t, savings, 1)
+	require.Equal(t, filepath.Join(catalog.Dir, "flat.imageset"), savings[0].ImageSet)
+	require.Equal(t, 3, total.Files)
+	require.True(t, total.Saved() > 0)
+}
@@ -0,0 +1,263 @@
+package asset
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ContactSheetOptions control how WriteContactSheet shows the images of a
+// catalog.
+type ContactSheetOptions struct {
+	// Title of the page. Defaults to the catalog folder name.
+	Title string
+	// Embed inlines every image as a data URI so that the page is a single
+	// self contained file. Otherwise images are linked relative to LinkDir,
+	// the folder the page is written to.
+	Embed   bool
+	LinkDir string
+	// Browse links section and set headings to their folders, for pages
+	// served by a CatalogServer.
+	Browse bool
+	// LiveReload, if set, is the URL of a server-sent event stream. The page
+	// reloads on every event.
+	LiveReload string
+}
+
+type sheetSection struct {
+	Name string
+	Link template.URL
+	Sets []sheetSet
+}
+
+type sheetSet struct {
+	Name    string
+	Kind    string
+	Link    template.URL
+	Entries []sheetEntry
+}
+
+type sheetEntry struct {
+	File   string
+	Src    template.URL
+	Width  int
+	Height int
+	Bytes  int64
+	Attrs  []sheetAttr
+}
+
+type sheetAttr struct {
+	Key, Value string
+}
+
+var contactSheetTemplate = template.Must(template.New("sheet").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font: 13px -apple-system, Helvetica, sans-serif; margin: 24px; color: #1d1d1f; }
+h2 { border-bottom: 1px solid #d2d2d7; padding-bottom: 4px; }
+.set { margin: 16px 0 32px; }
+.set h3 { margin: 0 0 8px; }
+.set h3 small { color: #86868b; font-weight: normal; }
+.entries { display: flex; flex-wrap: wrap; gap: 12px; }
+.entry { border: 1px solid #d2d2d7; border-radius: 6px; overflow: hidden; width: 280px; }
+.swatches { display: flex; }
+.swatch { flex: 1; height: 140px; display: flex; align-items: center; justify-content: center; }
+.swatch img { max-width: 128px; max-height: 128px; }
+.light { background: #ffffff; }
+.dark { background: #1c1c1e; }
+.info { padding: 8px; border-top: 1px solid #d2d2d7; }
+.info table { border-collapse: collapse; }
+.info td { padding: 1px 8px 1px 0; vertical-align: top; }
+.info td:first-child { color: #86868b; }
+.missing { color: #d70015; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .Sections}}<h2>{{if .Link}}<a href="{{.Link}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}</h2>
+{{range .Sets}}<div class="set">
+<h3>{{if .Link}}<a href="{{.Link}}">{{.Name}}</a>{{else}}{{.Name}}{{end}} <small>{{.Kind}}</small></h3>
+<div class="entries">
+{{range .Entries}}<div class="entry">
+<div class="swatches">{{if .Src}}<div class="swatch light"><img src="{{.Src}}" alt="{{.File}}"></div><div class="swatch dark"><img src="{{.Src}}" alt="{{.File}}"></div>{{else}}<div class="swatch light missing">no image</div>{{end}}</div>
+<div class="info">
+<strong>{{.File}}</strong>{{if .Width}}<br>{{.Width}}&times;{{.Height}} px, {{.Bytes}} bytes{{end}}
+<table>{{range .Attrs}}<tr><td>{{.Key}}</td><td>{{.Value}}</td></tr>{{end}}</table>
+</div>
+</div>
+{{end}}</div>
+</div>
+{{end}}{{end}}{{with .LiveReload}}<script>new EventSource("{{.}}").onmessage = function() { location.reload(); };</script>
+{{end}}</body>
+</html>
+`))
+
+// WriteContactSheet writes an HTML page to w showing every image set, app
+// icon and other set of images in the catalog at dir, grouped by folder. Each
+// entry is shown on light and dark backgrounds with its pixel size, file
+// size and Contents.json attributes.
+func WriteContactSheet(w io.Writer, dir string, opts ContactSheetOptions) error {
+	if opts.Title == "" {
+		opts.Title = filepath.Base(dir)
+	}
+	var sections []*sheetSection
+	byName := map[string]*sheetSection{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() || filepath.Ext(path) == "" {
+			return err
+		}
+		var contents struct {
+			Images []Image `json:"images"`
+		}
+		if ok, err := readContents(path, &contents); err != nil || !ok || len(contents.Images) == 0 {
+			return err
+		}
+		set, err := sheetSetFor(path, contents.Images, opts)
+		if err != nil {
+			return err
+		}
+		// A page of a single set shows it in the top section.
+		section := "."
+		if path != dir {
+			if section, err = filepath.Rel(dir, filepath.Dir(path)); err != nil {
+				return err
+			}
+		}
+		if byName[section] == nil {
+			byName[section] = &sheetSection{Name: section}
+			if opts.Browse && section != "." {
+				if byName[section].Link, err = sheetLink(filepath.Join(dir, section), opts); err != nil {
+					return err
+				}
+				byName[section].Link += "/"
+			}
+			sections = append(sections, byName[section])
+		}
+		if opts.Browse && path != dir {
+			if set.Link, err = sheetLink(path, opts); err != nil {
+				return err
+			}
+			set.Link += "/"
+		}
+		byName[section].Sets = append(byName[section].Sets, set)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// The catalog's own sets come first, then groups by path.
+	sort.SliceStable(sections, func(i, j int) bool {
+		if sections[i].Name == "." || sections[j].Name == "." {
+			return sections[i].Name == "."
+		}
+		return sections[i].Name < sections[j].Name
+	})
+	for _, s := range sections {
+		if s.Name == "." {
+			s.Name = filepath.Base(dir)
+		}
+	}
+	return contactSheetTemplate.Execute(w, struct {
+		Title      string
+		Sections   []*sheetSection
+		LiveReload string
+	}{opts.Title, sections, opts.LiveReload})
+}
+
+func sheetSetFor(dir string, images []Image, opts ContactSheetOptions) (sheetSet, error) {
+	ext := filepath.Ext(dir)
+	set := sheetSet{Name: strings.TrimSuffix(filepath.Base(dir), ext), Kind: ext[1:]}
+	for _, i := range images {
+		entry := sheetEntry{File: i.FileName}
+		attrs, err := imageAttrs(i)
+		if err != nil {
+			return set, err
+		}
+		entry.Attrs = attrs
+		if i.FileName != "" {
+			path := filepath.Join(dir, i.FileName)
+			if stat, err := os.Stat(path); err == nil {
+				entry.Bytes = stat.Size()
+				if entry.Src, err = sheetSrc(path, opts); err != nil {
+					return set, err
+				}
+				entry.Width, entry.Height, _ = imageSize(path)
+			}
+		}
+		set.Entries = append(set.Entries, entry)
+	}
+	return set, nil
+}
+
+// imageAttrs lists the Contents.json attributes of i other than its file
+// name, sorted by key.
+func imageAttrs(i Image) ([]sheetAttr, error) {
+	data, err := json.Marshal(i)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	var attrs []sheetAttr
+	for key, value := range fields {
+		if key == "filename" {
+			continue
+		}
+		str := fmt.Sprint(value)
+		if _, ok := value.(map[string]interface{}); ok {
+			raw, _ := json.Marshal(value)
+			str = string(raw)
+		}
+		attrs = append(attrs, sheetAttr{key, str})
+	}
+	sort.Slice(attrs, func(a, b int) bool { return attrs[a].Key < attrs[b].Key })
+	return attrs, nil
+}
+
+func sheetSrc(path string, opts ContactSheetOptions) (template.URL, error) {
+	if opts.Embed {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		typ := mime.TypeByExtension(filepath.Ext(path))
+		if typ == "" {
+			typ = "application/octet-stream"
+		}
+		return template.URL("data:" + typ + ";base64," + base64.StdEncoding.EncodeToString(data)), nil
+	}
+	return sheetLink(path, opts)
+}
+
+// sheetLink returns the URL of path relative to opts.LinkDir.
+func sheetLink(path string, opts ContactSheetOptions) (template.URL, error) {
+	base, err := filepath.Abs(opts.LinkDir)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(base, abs)
+	if err != nil {
+		return "", errors.Wrapf(err, "%s: cannot link from %s", path, opts.LinkDir)
+	}
+	return template.URL((&url.URL{Path: filepath.ToSlash(rel)}).String()), nil
+}
@@ -0,0 +1,45 @@
+package asset
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteContactSheet(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "preview-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{
+		"asset.yaml":     "scales: [1, 2]\n",
+		"z.svg":          testSVG,
+		"icons/lock.svg": testSVG,
+	})
+	catalog := newTestCatalog(t, tmpDir)
+	walker := &SVGWalker{Converter: NativeConverter{}, Catalog: catalog}
+	require.NoError(t, walker.Walk(src))
+	require.NoError(t, catalog.Write())
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteContactSheet(&buf, catalog.Dir, ContactSheetOptions{LinkDir: tmpDir}))
+	html := buf.String()
+	require.Contains(t, html, `<h2>Test.xcassets</h2>`)
+	require.Contains(t, html, `<h2>icons</h2>`)
+	require.True(t, strings.Index(html, "<h2>Test.xcassets</h2>") < strings.Index(html, "<h2>icons</h2>"))
+	require.Contains(t, html, `src="Test.xcassets/icons/lock.imageset/lock-2x.png"`)
+	require.Contains(t, html, `60&times;80 px`)
+	require.Contains(t, html, `<td>scale</td><td>2x</td>`)
+	require.Contains(t, html, `<td>idiom</td><td>universal</td>`)
+
+	buf.Reset()
+	require.NoError(t, WriteContactSheet(&buf, catalog.Dir, ContactSheetOptions{Embed: true, Title: "Review"}))
+	require.Contains(t, buf.String(), `<title>Review</title>`)
+	require.Contains(t, buf.String(), `src="data:image/png;base64,`)
+}
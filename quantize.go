@@ -0,0 +1,181 @@
+package asset
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultQuantizeMaxError is the default root mean square error, in 8 bit
+// channel units, above which a quantized PNG is discarded.
+const DefaultQuantizeMaxError = 6.0
+
+// QuantizePNG reduces the PNG at path to an 8-bit palette using median cut
+// and Floyd-Steinberg dithering. If the root mean square error of the result
+// exceeds maxError, or the result is not smaller, the file is left unchanged.
+// It returns whether the file was replaced along with the measured error.
+func QuantizePNG(path string, maxError float64) (bool, float64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, 0, err
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return false, 0, errors.Wrapf(err, "%s: failed to decode png", path)
+	}
+	b := img.Bounds()
+	if _, ok := img.(*image.Paletted); ok {
+		return false, 0, nil
+	}
+	p := image.NewPaletted(b, medianCut(img, 256))
+	draw.FloydSteinberg.Draw(p, b, img, b.Min)
+	rms := rmsError(img, p)
+	if rms > maxError {
+		return false, rms, nil
+	}
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(&buf, p); err != nil {
+		return false, rms, errors.Wrapf(err, "%s: failed to encode png", path)
+	}
+	if buf.Len() >= len(data) {
+		return false, rms, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, rms, err
+	}
+	return true, rms, ioutil.WriteFile(path, buf.Bytes(), info.Mode())
+}
+
+// rmsError compares the premultiplied channels of two images of the same
+// bounds.
+func rmsError(a, b image.Image) float64 {
+	bounds := a.Bounds()
+	var sum float64
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r1, g1, b1, a1 := a.At(x, y).RGBA()
+			r2, g2, b2, a2 := b.At(x, y).RGBA()
+			for _, d := range []float64{
+				float64(r1) - float64(r2),
+				float64(g1) - float64(g2),
+				float64(b1) - float64(b2),
+				float64(a1) - float64(a2),
+			} {
+				d /= 257
+				sum += d * d
+			}
+		}
+	}
+	n := float64(bounds.Dx() * bounds.Dy() * 4)
+	if n == 0 {
+		return 0
+	}
+	return math.Sqrt(sum / n)
+}
+
+type colorCount struct {
+	c     [4]uint8
+	count int
+}
+
+type colorBox []colorCount
+
+func (b colorBox) widest() (int, int) {
+	channel, width := 0, -1
+	for ch := 0; ch < 4; ch++ {
+		lo, hi := uint8(255), uint8(0)
+		for _, c := range b {
+			if c.c[ch] < lo {
+				lo = c.c[ch]
+			}
+			if c.c[ch] > hi {
+				hi = c.c[ch]
+			}
+		}
+		if w := int(hi) - int(lo); w > width {
+			channel, width = ch, w
+		}
+	}
+	return channel, width
+}
+
+func (b colorBox) average() color.NRGBA {
+	var sum [4]int
+	total := 0
+	for _, c := range b {
+		for ch := range sum {
+			sum[ch] += int(c.c[ch]) * c.count
+		}
+		total += c.count
+	}
+	return color.NRGBA{
+		uint8(sum[0] / total), uint8(sum[1] / total), uint8(sum[2] / total), uint8(sum[3] / total),
+	}
+}
+
+// medianCut returns a palette of at most size colors for img.
+func medianCut(img image.Image, size int) color.Palette {
+	counts := map[[4]uint8]int{}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A == 0 {
+				c = color.NRGBA{}
+			}
+			counts[[4]uint8{c.R, c.G, c.B, c.A}]++
+		}
+	}
+	all := make(colorBox, 0, len(counts))
+	for c, n := range counts {
+		all = append(all, colorCount{c, n})
+	}
+	boxes := []colorBox{all}
+	for len(boxes) < size {
+		// Split the box with the widest channel range.
+		idx, channel, width := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			if ch, w := box.widest(); w > width {
+				idx, channel, width = i, ch, w
+			}
+		}
+		if idx == -1 {
+			break
+		}
+		box := boxes[idx]
+		sort.Slice(box, func(i, j int) bool { return box[i].c[channel] < box[j].c[channel] })
+		half, seen := 0, 0
+		for _, c := range box {
+			half += c.count
+		}
+		half /= 2
+		split := 1
+		for i, c := range box[:len(box)-1] {
+			seen += c.count
+			if seen >= half {
+				split = i + 1
+				break
+			}
+		}
+		boxes = append(boxes, box[split:])
+		boxes[idx] = box[:split]
+	}
+	palette := make(color.Palette, len(boxes))
+	for i, box := range boxes {
+		palette[i] = box.average()
+	}
+	return palette
+}
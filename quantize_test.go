@@ -0,0 +1,89 @@
+package asset
+
+import (
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// gradient returns a noisy gradient with too many colors for a palette and
+// too little structure for PNG filters to compress well.
+func gradient(size int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			noise := uint8((x*7 + y*13 + x*y) % 9)
+			img.Set(x, y, color.NRGBA{uint8(x*200/size) + noise, uint8(y*200/size) + noise, 0x80 + noise, uint8(0xff - y*0x40/size)})
+		}
+	}
+	return img
+}
+
+func TestQuantizePNG(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "quantize-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "gradient.png")
+	encodeUncompressed(t, path, gradient(128))
+	replaced, rms, err := QuantizePNG(path, 0.01)
+	require.NoError(t, err)
+	require.False(t, replaced)
+	require.True(t, rms > 0.01)
+
+	replaced, rms, err = QuantizePNG(path, DefaultQuantizeMaxError)
+	require.NoError(t, err)
+	require.True(t, replaced, "rms %f", rms)
+	img, err := decodeImage(path)
+	require.NoError(t, err)
+	p, ok := img.(*image.Paletted)
+	require.True(t, ok, "expected a paletted image, got %T", img)
+	require.True(t, len(p.Palette) <= 256)
+
+	few := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for i := range few.Pix {
+		few.Pix[i] = uint8(i % 3 * 0x40)
+	}
+	path = filepath.Join(tmpDir, "few.png")
+	encodeUncompressed(t, path, few)
+	replaced, rms, err = QuantizePNG(path, 0)
+	require.NoError(t, err)
+	require.True(t, replaced)
+	require.Zero(t, rms)
+	requireSamePixels(t, few, path)
+}
+
+func TestSVGWalker_Quantize(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "quantize-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{"odr/asset.yaml": "quantize: true\n"})
+	encodeUncompressed(t, filepath.Join(src, "odr", "art@3x.png"), gradient(96))
+	encodeUncompressed(t, filepath.Join(src, "plain@3x.png"), gradient(96))
+	encodeUncompressed(t, filepath.Join(src, "glob@3x.png"), gradient(96))
+
+	catalog := newTestCatalog(t, tmpDir)
+	walker := &SVGWalker{Catalog: catalog, Quantize: []string{"glob*"}}
+	require.NoError(t, walker.Walk(src))
+	require.NoError(t, catalog.Write())
+
+	paletted := func(file string) bool {
+		img, err := decodeImage(filepath.Join(catalog.Dir, file))
+		require.NoError(t, err)
+		_, ok := img.(*image.Paletted)
+		return ok
+	}
+	require.True(t, paletted("odr/art.imageset/art-3x.png"))
+	require.True(t, paletted("glob.imageset/glob-3x.png"))
+	require.False(t, paletted("plain.imageset/plain-3x.png"))
+
+	savings, _ := walker.Savings()
+	require.Len(t, savings, 2)
+}
@@ -0,0 +1,232 @@
+package asset
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/image/draw"
+)
+
+var rasterExts = map[string]bool{".png": true, ".jpg": true, ".jpeg": true}
+
+func isRaster(name string) bool {
+	return rasterExts[strings.ToLower(filepath.Ext(name))]
+}
+
+var scaleSuffix = regexp.MustCompile(`^(.*)@([1-9][0-9]*)x$`)
+
+// rasterName splits a file name such as name@2x.png into its base name and
+// scale. Files without a scale suffix are treated as 1x.
+func rasterName(file string) (string, int) {
+	name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	if m := scaleSuffix.FindStringSubmatch(name); m != nil {
+		scale, _ := strconv.Atoi(m[2])
+		return m[1], scale
+	}
+	return name, 1
+}
+
+// rasterSet holds every resolution of a raster source seen so far.
+type rasterSet struct {
+	sources map[int]string
+}
+
+func (r *rasterSet) largest() (int, string) {
+	best := 0
+	for scale := range r.sources {
+		if scale > best {
+			best = scale
+		}
+	}
+	return best, r.sources[best]
+}
+
+func (s *SVGWalker) addRaster(c *Container, dir, file string, cfg *settings, identity, locale string) error {
+	path := filepath.Join(dir, file)
+	base, scale := rasterName(file)
+	target, err := cfg.imageSetNameFor(base)
+	if err != nil {
+		return err
+	}
+
+	image, err := s.imageSet(c, target, filepath.Join(identity, base+"@raster"), path)
+	if err != nil {
+		return err
+	}
+	image.Properties.OnDemandResourceTags = s.resourceTags(cfg, file)
+
+	v := variant{idiom: cfg.idiom}.localized(locale)
+	if s.rasters == nil {
+		s.rasters = map[string]*rasterSet{}
+	}
+	key := filepath.Join(image.Dir, v.name)
+	set := s.rasters[key]
+	if set == nil {
+		set = &rasterSet{sources: map[int]string{}}
+		s.rasters[key] = set
+	}
+	set.sources[scale] = path
+
+	files := make([]string, len(cfg.scales))
+	for i, scale := range cfg.scales {
+		if files[i], err = cfg.fileNameFor(target, scale, v); err != nil {
+			return err
+		}
+	}
+	if err := s.claimVariant(image, v, filepath.Join(filepath.Dir(path), base), files); err != nil {
+		return err
+	}
+	update, err := s.needsUpdate(image.Dir, variantImages(image.Images, v), path, len(cfg.scales))
+	if err != nil {
+		return err
+	}
+	s.report.source(filepath.Join(filepath.Dir(path), base), image.Dir, files, update)
+	if !update {
+		return nil
+	}
+	size := cfg.sizeFor(file)
+	slice := cfg.sliceFor(file)
+	var width, height float32
+	if slice != nil {
+		if width, height, err = rasterPoints(path, scale, size); err != nil {
+			return err
+		}
+	}
+	post := s.postOptions(cfg, file)
+	images := make([]Image, len(cfg.scales))
+	for i, scale := range cfg.scales {
+		images[i] = slice.apply(v.apply(Image{
+			Scale:     fmt.Sprintf("%dx", scale),
+			FileName:  files[i],
+			generator: s.rasterGenerator(image, post, set, scale, size, files[i]),
+		}), scale, width, height)
+	}
+	image.Images = replaceVariant(image.Images, v, images)
+	return nil
+}
+
+func (s *SVGWalker) rasterGenerator(i *ImageSet, post postOptions, set *rasterSet, scale int, size *Size, out string) func() error {
+	return func() error {
+		file := filepath.Join(i.Dir, out)
+		Log("Generating", file)
+		start := time.Now()
+		if src, ok := set.sources[scale]; ok && size == nil && strings.ToLower(filepath.Ext(src)) == ".png" {
+			if err := copyFile(src, file); err != nil {
+				return err
+			}
+			s.report.rendered(file, OutputGenerated, time.Since(start))
+			return s.postProcess(i, file, post)
+		}
+		srcScale, src := set.largest()
+		img, err := decodeImage(src)
+		if err != nil {
+			return err
+		}
+		b := img.Bounds()
+		w := float64(b.Dx()) * float64(scale) / float64(srcScale)
+		h := float64(b.Dy()) * float64(scale) / float64(srcScale)
+		if size != nil {
+			if size.Width > 0 {
+				w = float64(size.Width) * float64(scale)
+			}
+			if size.Height > 0 {
+				h = float64(size.Height) * float64(scale)
+			}
+		}
+		width, height := int(math.Round(w)), int(math.Round(h))
+		if width > b.Dx() || height > b.Dy() {
+			if !s.AllowUpscale {
+				return errors.Errorf("%s: refusing to upscale %dx%d to %dx%d for %dx", src, b.Dx(), b.Dy(), width, height, scale)
+			}
+			s.report.warn(file, fmt.Sprintf("upscaled from %dx%d", b.Dx(), b.Dy()))
+		}
+		if err := writePNG(file, resample(img, width, height)); err != nil {
+			return err
+		}
+		s.report.rendered(file, OutputGenerated, time.Since(start))
+		return s.postProcess(i, file, post)
+	}
+}
+
+// rasterPoints returns the size in points of a raster source at scale, or
+// of its size override.
+func rasterPoints(path string, scale int, size *Size) (float32, float32, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	c, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "%s: failed to decode image", path)
+	}
+	w, h := float32(c.Width)/float32(scale), float32(c.Height)/float32(scale)
+	if size != nil {
+		if size.Width > 0 {
+			w = size.Width
+		}
+		if size.Height > 0 {
+			h = size.Height
+		}
+	}
+	return w, h, nil
+}
+
+func resample(src image.Image, width, height int) image.Image {
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+	return dst
+}
+
+func decodeImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: failed to decode image", path)
+	}
+	return img, nil
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return errors.Wrapf(err, "%s: failed to encode png", path)
+	}
+	return f.Close()
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,89 @@
+package asset
+
+import (
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestPNG(t *testing.T, path string, width, height int) {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{uint8(x), uint8(y), 0x80, 0xff})
+		}
+	}
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, writePNG(path, img))
+}
+
+func pngSize(t *testing.T, path string) (int, int) {
+	img, err := decodeImage(path)
+	require.NoError(t, err)
+	return img.Bounds().Dx(), img.Bounds().Dy()
+}
+
+func TestRasterName(t *testing.T) {
+	for file, expected := range map[string]struct {
+		name  string
+		scale int
+	}{
+		"a/hero.png":      {"hero", 1},
+		"hero@2x.png":     {"hero", 2},
+		"hero@3x.jpeg":    {"hero", 3},
+		"hero@two.png":    {"hero@two", 1},
+		"my icon@12x.JPG": {"my icon", 12},
+	} {
+		name, scale := rasterName(file)
+		require.Equal(t, expected.name, name, file)
+		require.Equal(t, expected.scale, scale, file)
+	}
+}
+
+func TestSVGWalker_Raster(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "raster-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTestPNG(t, filepath.Join(src, "hero@3x.png"), 90, 60)
+	writeTestPNG(t, filepath.Join(src, "icon@2x.png"), 40, 40)
+	writeTestPNG(t, filepath.Join(src, "icon@3x.png"), 60, 60)
+
+	catalog := newTestCatalog(t, tmpDir)
+	walker := &SVGWalker{Converter: &recordingConverter{}, Catalog: catalog}
+	require.NoError(t, walker.Walk(src))
+	require.NoError(t, catalog.Write())
+
+	for file, size := range map[string][2]int{
+		"hero.imageset/hero-1x.png": {30, 20},
+		"hero.imageset/hero-2x.png": {60, 40},
+		"hero.imageset/hero-3x.png": {90, 60},
+		"icon.imageset/icon-1x.png": {20, 20},
+		"icon.imageset/icon-2x.png": {40, 40},
+	} {
+		w, h := pngSize(t, filepath.Join(catalog.Dir, file))
+		require.Equal(t, size, [2]int{w, h}, file)
+	}
+	original, err := ioutil.ReadFile(filepath.Join(src, "icon@2x.png"))
+	require.NoError(t, err)
+	copied, err := ioutil.ReadFile(filepath.Join(catalog.Dir, "icon.imageset/icon-2x.png"))
+	require.NoError(t, err)
+	require.Equal(t, original, copied)
+
+	writeTestPNG(t, filepath.Join(src, "small.png"), 10, 10)
+	walker.ForceUpdate = true
+	require.NoError(t, walker.Walk(src))
+	require.Error(t, catalog.Write())
+
+	walker.AllowUpscale = true
+	require.NoError(t, walker.Walk(src))
+	require.NoError(t, catalog.Write())
+	w, h := pngSize(t, filepath.Join(catalog.Dir, "small.imageset/small-3x.png"))
+	require.Equal(t, [2]int{30, 30}, [2]int{w, h})
+}
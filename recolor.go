@@ -0,0 +1,149 @@
+package asset
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/image/colornames"
+)
+
+// ColorMap maps the colors of an SVG's fills and strokes to new colors.
+// Keys are CSS colors, currentColor or "*" for every color not otherwise
+// mapped. Values are CSS colors.
+type ColorMap map[string]string
+
+// RecolorRule adds an image set named <name>-<Suffix> for every SVG whose
+// path, relative to the walked directory, matches Pattern, rendered with
+// its colors mapped by Colors.
+type RecolorRule struct {
+	Pattern string
+	Suffix  string
+	Colors  ColorMap
+}
+
+type recolorRule struct {
+	globRule
+	suffix string
+	colors ColorMap
+}
+
+var hexColor = regexp.MustCompile(`^#([0-9a-f]{3}|[0-9a-f]{6}|[0-9a-f]{8})$`)
+
+// normalizeColor lower cases c and expands short hex and named colors so
+// that equal colors compare equal.
+func normalizeColor(c string) string {
+	c = strings.ToLower(strings.TrimSpace(c))
+	if len(c) == 4 && hexColor.MatchString(c) {
+		return "#" + strings.Repeat(c[1:2], 2) + strings.Repeat(c[2:3], 2) + strings.Repeat(c[3:4], 2)
+	}
+	if n, ok := colornames.Map[c]; ok {
+		return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B)
+	}
+	return c
+}
+
+func validColor(c string) bool {
+	return c == "currentcolor" || hexColor.MatchString(c) ||
+		strings.HasPrefix(c, "rgb(") || strings.HasPrefix(c, "rgba(") ||
+		strings.HasPrefix(c, "hsl(") || strings.HasPrefix(c, "hsla(")
+}
+
+func newRecolorRule(base, pattern, suffix string, colors ColorMap) (recolorRule, error) {
+	if suffix == "" {
+		return recolorRule{}, errors.Errorf("%s: recolor suffix is empty", pattern)
+	}
+	normalized := ColorMap{}
+	for from, to := range colors {
+		key, value := normalizeColor(from), normalizeColor(to)
+		if (key != "*" && !validColor(key)) || !validColor(value) {
+			return recolorRule{}, errors.Errorf("%s: invalid recolor %s -> %s", pattern, from, to)
+		}
+		normalized[key] = value
+	}
+	return recolorRule{globRule{base, pattern}, suffix, normalized}, nil
+}
+
+// recolorsFor returns the recolored copies of file, merging the colors of
+// rules with the same suffix.
+func (p *settings) recolorsFor(file string) []recolorRule {
+	merged := map[string]ColorMap{}
+	for _, r := range p.recolor {
+		if !r.match(file) {
+			continue
+		}
+		if merged[r.suffix] == nil {
+			merged[r.suffix] = ColorMap{}
+		}
+		for from, to := range r.colors {
+			merged[r.suffix][from] = to
+		}
+	}
+	var rules []recolorRule
+	for suffix, colors := range merged {
+		rules = append(rules, recolorRule{suffix: suffix, colors: colors})
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].suffix < rules[j].suffix })
+	return rules
+}
+
+func (m ColorMap) mapColor(value string) string {
+	// Only paints are mapped, leaving none, url(...) and non-color values
+	// such as the fill="freeze" of animations alone.
+	c := normalizeColor(value)
+	if !validColor(c) {
+		return value
+	}
+	if to, ok := m[c]; ok {
+		return to
+	}
+	if to, ok := m["*"]; ok {
+		return to
+	}
+	return value
+}
+
+var (
+	colorAttr     = regexp.MustCompile(`(\s)(fill|stroke|stop-color|flood-color)(\s*=\s*)("[^"]*"|'[^']*')`)
+	colorProperty = regexp.MustCompile(`([\s;{"'])(fill|stroke|stop-color|flood-color)(\s*:\s*)([^;"'}!<]+)`)
+	// fillDeclaration matches a fill property in a style attribute.
+	fillDeclaration = regexp.MustCompile(`(^|;)\s*fill\s*:`)
+)
+
+// recolorSVG maps the colors of the fill, stroke and gradient stop
+// attributes and style properties of data. Since fills default to black, a
+// root without a fill is given an explicit black fill first.
+func recolorSVG(data []byte, colors ColorMap) ([]byte, error) {
+	root, open, _, err := svgRoot(data)
+	if err != nil {
+		return nil, err
+	}
+	filled := false
+	for _, a := range root.Attr {
+		filled = filled || a.Name.Local == "fill" || (a.Name.Local == "style" && fillDeclaration.MatchString(a.Value))
+	}
+	if !filled {
+		at := open - 1
+		if at > 0 && data[at-1] == '/' {
+			at--
+		}
+		var buf bytes.Buffer
+		buf.Write(data[:at])
+		buf.WriteString(` fill="black"`)
+		buf.Write(data[at:])
+		data = buf.Bytes()
+	}
+	data = colorAttr.ReplaceAllFunc(data, func(m []byte) []byte {
+		g := colorAttr.FindSubmatch(m)
+		quote, value := g[4][:1], string(g[4][1:len(g[4])-1])
+		return []byte(fmt.Sprintf("%s%s%s%s%s%s", g[1], g[2], g[3], quote, colors.mapColor(value), quote))
+	})
+	data = colorProperty.ReplaceAllFunc(data, func(m []byte) []byte {
+		g := colorProperty.FindSubmatch(m)
+		return []byte(fmt.Sprintf("%s%s%s%s", g[1], g[2], g[3], colors.mapColor(string(g[4]))))
+	})
+	return data, nil
+}
@@ -0,0 +1,86 @@
+package asset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecolorSVG(t *testing.T) {
+	for _, c := range []struct {
+		svg, expected string
+		colors        ColorMap
+	}{
+		{
+			`<svg><path fill="#F00" stroke='currentColor'/></svg>`,
+			`<svg fill="black"><path fill="#0000ff" stroke='currentColor'/></svg>`,
+			ColorMap{"#ff0000": "#0000ff"},
+		},
+		{
+			`<svg fill="none"><path stroke="currentColor" style="fill: red; stroke-width:2"/></svg>`,
+			`<svg fill="none"><path stroke="#00ff00" style="fill: red; stroke-width:2"/></svg>`,
+			ColorMap{"currentcolor": "#00ff00"},
+		},
+		{
+			`<svg><rect fill="url(#g)"/><stop stop-color="white"/><style>.a{fill:#000}</style></svg>`,
+			`<svg fill="#123456"><rect fill="url(#g)"/><stop stop-color="#123456"/><style>.a{fill:#123456}</style></svg>`,
+			ColorMap{"*": "#123456"},
+		},
+		{
+			`<svg style="fill-opacity:.5"><rect fill="red"><animate fill="freeze"/><set fill='remove'/></rect></svg>`,
+			`<svg style="fill-opacity:.5" fill="#123456"><rect fill="#123456"><animate fill="freeze"/><set fill='remove'/></rect></svg>`,
+			ColorMap{"*": "#123456"},
+		},
+		{
+			`<svg style="stroke:red; fill :blue"/>`,
+			`<svg style="stroke:#123456; fill :#123456"/>`,
+			ColorMap{"*": "#123456"},
+		},
+	} {
+		out, err := recolorSVG([]byte(c.svg), c.colors)
+		require.NoError(t, err)
+		require.Equal(t, c.expected, string(out))
+	}
+}
+
+func TestSVGWalker_Recolor(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "recolor-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{
+		"asset.yaml": "scales: [1]\nrecolor:\n  'lock*.svg':\n    danger: {'*': red}\n",
+		"lock.svg":   `<svg xmlns="http://www.w3.org/2000/svg" width="4" height="4"><rect width="4" height="4"/></svg>`,
+		"plain.svg":  testSVG,
+	})
+	catalog := newTestCatalog(t, tmpDir)
+	walker := &SVGWalker{
+		Converter: NativeConverter{},
+		Catalog:   catalog,
+		Recolor:   []RecolorRule{{Pattern: "lock.svg", Suffix: "primary", Colors: ColorMap{"black": "#0000FF"}}},
+	}
+	require.NoError(t, walker.Walk(src))
+	require.NoError(t, catalog.Write())
+
+	require.Len(t, catalog.Images, 4)
+	for name, expected := range map[string][3]uint32{
+		"lock":         {0, 0, 0},
+		"lock-primary": {0, 0, 0xffff},
+		"lock-danger":  {0xffff, 0, 0},
+	} {
+		image := catalog.Images[name]
+		require.NotNil(t, image, name)
+		require.Equal(t, name+"-1x.png", image.Images[0].FileName)
+		img, err := decodeImage(filepath.Join(image.Dir, image.Images[0].FileName))
+		require.NoError(t, err)
+		r, g, b, _ := img.At(2, 2).RGBA()
+		require.Equal(t, expected, [3]uint32{r, g, b}, name)
+	}
+
+	walker.Recolor = []RecolorRule{{Pattern: "*.svg", Suffix: "bad", Colors: ColorMap{"*": "nope"}}}
+	require.Error(t, walker.Walk(src))
+}
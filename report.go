@@ -0,0 +1,173 @@
+package asset
+
+import (
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Output statuses of a Report.
+const (
+	// OutputGenerated PNGs were rendered during the run.
+	OutputGenerated = "generated"
+	// OutputCached PNGs were copied from the render cache.
+	OutputCached = "cached"
+	// OutputSkipped PNGs were up to date and left untouched.
+	OutputSkipped = "skipped"
+	// OutputPending PNGs are rendered once the catalog is written.
+	OutputPending = "pending"
+)
+
+// Report lists every source of a run along with its outputs.
+type Report struct {
+	Sources []SourceReport `json:"sources"`
+	// Warnings not tied to a single output.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type SourceReport struct {
+	// Source is the path of the source, empty for PNGs generated by the
+	// Add methods, such as AddLaunchImageSVG, that are not tracked by source.
+	Source   string         `json:"source"`
+	ImageSet string         `json:"image-set"`
+	Outputs  []OutputReport `json:"outputs"`
+}
+
+type OutputReport struct {
+	File   string `json:"file"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Bytes  int64  `json:"bytes"`
+	Status string `json:"status"`
+	// ConvertMillis is the time taken to render the PNG.
+	ConvertMillis float64  `json:"convert-ms,omitempty"`
+	Warnings      []string `json:"warnings,omitempty"`
+}
+
+type render struct {
+	status   string
+	duration time.Duration
+}
+
+// buildLog records the sources and renders of a run for Report.
+type buildLog struct {
+	sync.Mutex
+	sources  []*SourceReport
+	renders  map[string]render
+	warnings map[string][]string
+	general  []string
+}
+
+// source records that source has the given files in dir, which are
+// regenerated when the catalog is written if update is set.
+func (l *buildLog) source(source, dir string, files []string, update bool) {
+	l.Lock()
+	defer l.Unlock()
+	var r *SourceReport
+	for _, existing := range l.sources {
+		if existing.Source == source && existing.ImageSet == dir {
+			r = existing
+		}
+	}
+	if r == nil {
+		r = &SourceReport{Source: source, ImageSet: dir}
+		l.sources = append(l.sources, r)
+	}
+	status := OutputSkipped
+	if update {
+		status = OutputPending
+	}
+	r.Outputs = r.Outputs[:0]
+	for _, f := range files {
+		r.Outputs = append(r.Outputs, OutputReport{File: filepath.Join(dir, f), Status: status})
+	}
+}
+
+func (l *buildLog) reset() {
+	l.Lock()
+	defer l.Unlock()
+	l.sources, l.renders, l.warnings, l.general = nil, nil, nil, nil
+}
+
+func (l *buildLog) rendered(file, status string, d time.Duration) {
+	l.Lock()
+	defer l.Unlock()
+	if l.renders == nil {
+		l.renders = map[string]render{}
+	}
+	l.renders[file] = render{status, d}
+}
+
+// warn records a warning about the output file, or about the run if file is
+// empty, and logs it.
+func (l *buildLog) warn(file, msg string) {
+	Log("WARNING:", file, msg)
+	l.Lock()
+	defer l.Unlock()
+	if file == "" {
+		l.general = append(l.general, msg)
+		return
+	}
+	if l.warnings == nil {
+		l.warnings = map[string][]string{}
+	}
+	l.warnings[file] = append(l.warnings[file], msg)
+}
+
+// Report returns the sources added since the last Walk and the outputs of
+// each. Outputs are described as they are on disk, so Report should be
+// called after the catalog is written.
+func (s *SVGWalker) Report() *Report {
+	l := &s.report
+	l.Lock()
+	defer l.Unlock()
+	report := &Report{Warnings: append([]string(nil), l.general...)}
+	seen := map[string]bool{}
+	for _, src := range l.sources {
+		r := SourceReport{Source: src.Source, ImageSet: src.ImageSet}
+		for _, o := range src.Outputs {
+			seen[o.File] = true
+			r.Outputs = append(r.Outputs, l.output(o.File, o.Status))
+		}
+		report.Sources = append(report.Sources, r)
+	}
+	// Renders of the Add methods, grouped by folder.
+	var untracked []string
+	for file := range l.renders {
+		if !seen[file] {
+			untracked = append(untracked, file)
+		}
+	}
+	sort.Strings(untracked)
+	for _, file := range untracked {
+		dir := filepath.Dir(file)
+		n := len(report.Sources)
+		if n == 0 || report.Sources[n-1].Source != "" || report.Sources[n-1].ImageSet != dir {
+			report.Sources = append(report.Sources, SourceReport{ImageSet: dir})
+			n++
+		}
+		report.Sources[n-1].Outputs = append(report.Sources[n-1].Outputs, l.output(file, OutputPending))
+	}
+	return report
+}
+
+func (l *buildLog) output(file, status string) OutputReport {
+	o := OutputReport{File: file, Status: status, Warnings: l.warnings[file]}
+	if r, ok := l.renders[file]; ok && status != OutputSkipped {
+		o.Status = r.status
+		o.ConvertMillis = float64(r.duration) / float64(time.Millisecond)
+	}
+	if stat, err := os.Stat(file); err == nil {
+		o.Bytes = stat.Size()
+	}
+	if f, err := os.Open(file); err == nil {
+		if cfg, err := png.DecodeConfig(f); err == nil {
+			o.Width, o.Height = cfg.Width, cfg.Height
+		}
+		f.Close()
+	}
+	return o
+}
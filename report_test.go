@@ -0,0 +1,56 @@
+package asset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSVGWalker_Report(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "report-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{
+		"asset.yaml": "scales: [1, 2]\n",
+		"a.svg":      testSVG,
+		"b.svg":      testSVG,
+	})
+	logo := filepath.Join(tmpDir, "logo.svg")
+	require.NoError(t, ioutil.WriteFile(logo, []byte(testLogoSVG), 0600))
+
+	walk := func() *Report {
+		catalog := newTestCatalog(t, tmpDir)
+		walker := &SVGWalker{Converter: NativeConverter{}, Catalog: catalog}
+		require.NoError(t, walker.Walk(src))
+		require.NoError(t, walker.AddLaunchBackgroundSVG(logo, LaunchImageOptions{Size: Size{10, 10}}))
+		require.NoError(t, catalog.Write())
+		return walker.Report()
+	}
+
+	report := walk()
+	require.Len(t, report.Sources, 3)
+	a := report.Sources[0]
+	require.Equal(t, filepath.Join(src, "a.svg"), a.Source)
+	require.Equal(t, filepath.Join(tmpDir, "Test.xcassets", "a.imageset"), a.ImageSet)
+	require.Len(t, a.Outputs, 2)
+	out := a.Outputs[1]
+	require.Equal(t, filepath.Join(a.ImageSet, "a-2x.png"), out.File)
+	require.Equal(t, OutputGenerated, out.Status)
+	require.Equal(t, []int{60, 80}, []int{out.Width, out.Height})
+	require.True(t, out.Bytes > 0)
+
+	launch := report.Sources[2]
+	require.Equal(t, "", launch.Source)
+	require.Equal(t, "LaunchBackground.imageset", filepath.Base(launch.ImageSet))
+	require.Equal(t, OutputGenerated, launch.Outputs[0].Status)
+
+	report = walk()
+	require.Equal(t, OutputSkipped, report.Sources[0].Outputs[1].Status)
+	require.Equal(t, 80, report.Sources[0].Outputs[1].Height)
+	require.Zero(t, report.Sources[0].Outputs[1].ConvertMillis)
+}
@@ -0,0 +1,116 @@
+package asset
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// EventsPath is the path of the server-sent event stream of a
+// CatalogServer.
+const EventsPath = "/_events"
+
+// CatalogServer serves contact sheets of a catalog and its folders along
+// with the images they show. Pages reload through server-sent events
+// whenever Reload is called.
+type CatalogServer struct {
+	Dir string
+
+	mu      sync.Mutex
+	clients map[chan struct{}]bool
+}
+
+func NewCatalogServer(dir string) *CatalogServer {
+	return &CatalogServer{Dir: dir, clients: map[chan struct{}]bool{}}
+}
+
+// Reload tells every open page to reload.
+func (s *CatalogServer) Reload() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.clients {
+		select {
+		case c <- struct{}{}:
+		default:
+			// A reload is already pending for this page.
+		}
+	}
+}
+
+func (s *CatalogServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == EventsPath {
+		s.serveEvents(w, r)
+		return
+	}
+	urlPath := path.Clean("/" + r.URL.Path)
+	file := filepath.Join(s.Dir, filepath.FromSlash(urlPath))
+	stat, err := os.Stat(file)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	if !stat.IsDir() {
+		http.ServeFile(w, r, file)
+		return
+	}
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		http.Redirect(w, r, r.URL.EscapedPath()+"/", http.StatusMovedPermanently)
+		return
+	}
+	title := filepath.Base(s.Dir)
+	if urlPath != "/" {
+		title += urlPath
+	}
+	var buf bytes.Buffer
+	err = WriteContactSheet(&buf, file, ContactSheetOptions{
+		Title:      title,
+		LinkDir:    file,
+		Browse:     true,
+		LiveReload: EventsPath,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+func (s *CatalogServer) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	c := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.clients[c] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, c)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-c:
+			if _, err := fmt.Fprint(w, "data: reload\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
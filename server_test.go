@@ -0,0 +1,79 @@
+package asset
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogServer(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "server-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{
+		"icons/lock.svg": testSVG,
+	})
+	catalog := newTestCatalog(t, tmpDir)
+	walker := &SVGWalker{Converter: NativeConverter{}, Catalog: catalog}
+	require.NoError(t, walker.Walk(src))
+	require.NoError(t, catalog.Write())
+
+	server := NewCatalogServer(catalog.Dir)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	get := func(path string) (*http.Response, string) {
+		resp, err := http.Get(ts.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	resp, body := get("/")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, body, `new EventSource(`)
+	require.Contains(t, body, `<a href="icons/">icons</a>`)
+	require.Contains(t, body, `src="icons/lock.imageset/lock-1x.png"`)
+
+	resp, body = get("/icons")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "/icons/", resp.Request.URL.Path)
+	require.Contains(t, body, `<a href="lock.imageset/">lock</a>`)
+
+	resp, body = get("/icons/lock.imageset/")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, body, `<h2>lock.imageset</h2>`)
+	require.Contains(t, body, `src="lock-2x.png"`)
+	require.NotContains(t, body, `..`)
+
+	resp, body = get("/icons/lock.imageset/lock-1x.png")
+	require.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	require.Equal(t, "\x89PNG", body[:4])
+
+	require.NoError(t, os.Mkdir(filepath.Join(catalog.Dir, "a#b"), 0700))
+	resp, _ = get("/a%23b")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "/a%23b/", resp.Request.URL.EscapedPath())
+
+	resp, _ = get("/missing")
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	events, err := http.Get(ts.URL + EventsPath)
+	require.NoError(t, err)
+	defer events.Body.Close()
+	require.Equal(t, "text/event-stream", events.Header.Get("Content-Type"))
+	server.Reload()
+	line, err := bufio.NewReader(events.Body).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "data: reload\n", line)
+}
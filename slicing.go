@@ -0,0 +1,180 @@
+package asset
+
+import (
+	"encoding/xml"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Insets are distances from the edges of an image, in pixels when written to
+// a catalog and in points in a Slice.
+type Insets struct {
+	Top    float32 `json:"top" yaml:"top"`
+	Left   float32 `json:"left" yaml:"left"`
+	Bottom float32 `json:"bottom" yaml:"bottom"`
+	Right  float32 `json:"right" yaml:"right"`
+}
+
+func (i Insets) scaled(scale int) *Insets {
+	round := func(v float32) float32 { return float32(math.Round(float64(v * float32(scale)))) }
+	return &Insets{round(i.Top), round(i.Left), round(i.Bottom), round(i.Right)}
+}
+
+// Resizing and center modes of an image entry.
+const (
+	ResizingNinePart            = "9-part"
+	ResizingThreePartHorizontal = "3-part-horizontal"
+	ResizingThreePartVertical   = "3-part-vertical"
+	ResizingCenterStretch       = "stretch"
+	ResizingCenterTile          = "tile"
+)
+
+// ResizingCenter describes how the center of a sliced image fills the
+// space between the caps. Width and Height are in pixels.
+type ResizingCenter struct {
+	Mode   string  `json:"mode"`
+	Width  float32 `json:"width,omitempty"`
+	Height float32 `json:"height,omitempty"`
+}
+
+// Resizing is the slicing of an image entry, as edited in Xcode's slicing
+// inspector.
+type Resizing struct {
+	Mode      string          `json:"mode"`
+	Center    *ResizingCenter `json:"center,omitempty"`
+	CapInsets Insets          `json:"cap-insets"`
+}
+
+// Slice specifies, in points, how a source is sliced. It is set in configs
+// or in SVG metadata using the data-cap-insets, data-resizing-center and
+// data-alignment-insets attributes of the root element. The data- prefix may
+// be dropped for attributes in another namespace. Insets in metadata are
+// written like CSS margins: one, two or four values in the order top, right,
+// bottom, left.
+type Slice struct {
+	CapInsets *Insets `json:"cap-insets,omitempty" yaml:"cap-insets,omitempty"`
+	// Center is stretch or tile. Defaults to stretch.
+	Center          string  `json:"center,omitempty" yaml:"center,omitempty"`
+	AlignmentInsets *Insets `json:"alignment-insets,omitempty" yaml:"alignment-insets,omitempty"`
+}
+
+type sliceRule struct {
+	globRule
+	slice Slice
+}
+
+// sliceFor returns the slicing configured for file, preferring the most
+// recently merged matching rule.
+func (p *settings) sliceFor(file string) *Slice {
+	for i := len(p.slices) - 1; i >= 0; i-- {
+		if p.slices[i].match(file) {
+			return &p.slices[i].slice
+		}
+	}
+	return nil
+}
+
+func (s *Slice) validate(source string) error {
+	switch s.Center {
+	case "", ResizingCenterStretch, ResizingCenterTile:
+	default:
+		return errors.Errorf("%s: unknown resizing center %q", source, s.Center)
+	}
+	for _, i := range []*Insets{s.CapInsets, s.AlignmentInsets} {
+		if i != nil && (i.Top < 0 || i.Left < 0 || i.Bottom < 0 || i.Right < 0) {
+			return errors.Errorf("%s: negative insets", source)
+		}
+	}
+	return nil
+}
+
+// apply sets the resizing and alignment insets of an image of the given
+// size in points rendered at scale.
+func (s *Slice) apply(i Image, scale int, width, height float32) Image {
+	if s == nil {
+		return i
+	}
+	if s.AlignmentInsets != nil {
+		i.AlignmentInsets = s.AlignmentInsets.scaled(scale)
+	}
+	if s.CapInsets == nil {
+		return i
+	}
+	caps := *s.CapInsets.scaled(scale)
+	center := &ResizingCenter{Mode: s.Center}
+	if center.Mode == "" {
+		center.Mode = ResizingCenterStretch
+	}
+	horiz, vert := caps.Left+caps.Right > 0, caps.Top+caps.Bottom > 0
+	if horiz {
+		center.Width = float32(math.Max(1, math.Round(float64(width*float32(scale)-caps.Left-caps.Right))))
+	}
+	if vert {
+		center.Height = float32(math.Max(1, math.Round(float64(height*float32(scale)-caps.Top-caps.Bottom))))
+	}
+	mode := ResizingNinePart
+	switch {
+	case horiz && !vert:
+		mode = ResizingThreePartHorizontal
+	case vert && !horiz:
+		mode = ResizingThreePartVertical
+	}
+	i.Resizing = &Resizing{Mode: mode, Center: center, CapInsets: caps}
+	return i
+}
+
+// svgSlice reads slicing metadata from the attributes of an SVG root element.
+// It returns nil if there is none.
+func svgSlice(attrs []xml.Attr) (*Slice, error) {
+	var (
+		s     Slice
+		found bool
+	)
+	for _, a := range attrs {
+		var err error
+		switch strings.TrimPrefix(a.Name.Local, "data-") {
+		case "cap-insets":
+			s.CapInsets, err = parseInsets(a.Value)
+		case "alignment-insets":
+			s.AlignmentInsets, err = parseInsets(a.Value)
+		case "resizing-center":
+			s.Center = strings.TrimSpace(a.Value)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s", a.Name.Local)
+		}
+		found = true
+	}
+	if !found {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+// parseInsets parses CSS style insets: "all", "vertical horizontal" or
+// "top right bottom left", separated by spaces or commas.
+func parseInsets(str string) (*Insets, error) {
+	fields := strings.FieldsFunc(str, func(r rune) bool { return r == ',' || r == ' ' })
+	v := make([]float32, len(fields))
+	for i, f := range fields {
+		n, err := strconv.ParseFloat(strings.TrimSuffix(f, "px"), 32)
+		if err != nil {
+			return nil, err
+		}
+		v[i] = float32(n)
+	}
+	switch len(v) {
+	case 1:
+		return &Insets{v[0], v[0], v[0], v[0]}, nil
+	case 2:
+		return &Insets{Top: v[0], Left: v[1], Bottom: v[0], Right: v[1]}, nil
+	case 4:
+		return &Insets{Top: v[0], Right: v[1], Bottom: v[2], Left: v[3]}, nil
+	}
+	return nil, errors.Errorf("expected 1, 2 or 4 values, got %q", str)
+}
@@ -0,0 +1,89 @@
+package asset
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInsets(t *testing.T) {
+	for str, expected := range map[string]Insets{
+		"4":           {4, 4, 4, 4},
+		"4 8":         {Top: 4, Left: 8, Bottom: 4, Right: 8},
+		"1,2,3,4":     {Top: 1, Right: 2, Bottom: 3, Left: 4},
+		"1px 2 3 4px": {Top: 1, Right: 2, Bottom: 3, Left: 4},
+	} {
+		i, err := parseInsets(str)
+		require.NoError(t, err, str)
+		require.Equal(t, expected, *i, str)
+	}
+	for _, str := range []string{"", "1 2 3", "a"} {
+		_, err := parseInsets(str)
+		require.Error(t, err, str)
+	}
+}
+
+func TestSVGWalker_Slicing(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "slicing-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{
+		"asset.yaml": "scales: [1, 2]\nslices:\n  'button*.svg':\n    cap-insets: {left: 5, right: 5}\n    center: tile\n",
+		"bubble.svg": `<svg xmlns="http://www.w3.org/2000/svg" width="40" height="30" data-cap-insets="10 12" data-alignment-insets="0 0 2 0"></svg>`,
+		"button.svg": testSVG,
+		"plain.svg":  testSVG,
+	})
+
+	catalog := newTestCatalog(t, tmpDir)
+	walker := &SVGWalker{Converter: &recordingConverter{}, Catalog: catalog}
+	require.NoError(t, walker.Walk(src))
+	require.NoError(t, catalog.Write())
+
+	bubble := catalog.Images["bubble"].Images[1]
+	require.Equal(t, &Resizing{
+		Mode:      ResizingNinePart,
+		Center:    &ResizingCenter{Mode: ResizingCenterStretch, Width: 32, Height: 20},
+		CapInsets: Insets{Top: 20, Left: 24, Bottom: 20, Right: 24},
+	}, bubble.Resizing)
+	require.Equal(t, &Insets{Bottom: 4}, bubble.AlignmentInsets)
+
+	button := catalog.Images["button"].Images[0]
+	require.Equal(t, &Resizing{
+		Mode:      ResizingThreePartHorizontal,
+		Center:    &ResizingCenter{Mode: ResizingCenterTile, Width: 20},
+		CapInsets: Insets{Left: 5, Right: 5},
+	}, button.Resizing)
+
+	require.Nil(t, catalog.Images["plain"].Images[0].Resizing)
+
+	data, err := ioutil.ReadFile(filepath.Join(catalog.Images["bubble"].Dir, "Contents.json"))
+	require.NoError(t, err)
+	var contents struct {
+		Images []map[string]interface{} `json:"images"`
+	}
+	require.NoError(t, json.Unmarshal(data, &contents))
+	require.Equal(t, map[string]interface{}{
+		"mode":       "9-part",
+		"center":     map[string]interface{}{"mode": "stretch", "width": 32.0, "height": 20.0},
+		"cap-insets": map[string]interface{}{"top": 20.0, "left": 24.0, "bottom": 20.0, "right": 24.0},
+	}, contents.Images[1]["resizing"])
+}
+
+func TestSVGWalker_SlicingInvalid(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "slicing-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{
+		"bubble.svg": `<svg xmlns="http://www.w3.org/2000/svg" data-cap-insets="1" data-resizing-center="wobble"></svg>`,
+	})
+	walker := &SVGWalker{Converter: &recordingConverter{}, Catalog: newTestCatalog(t, tmpDir)}
+	require.Error(t, walker.Walk(src))
+}
@@ -0,0 +1,237 @@
+package asset
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// isSprite reports whether file is a sprite sheet split into image sets.
+func (p *settings) isSprite(file string) bool {
+	if filepath.Ext(file) != ".svg" {
+		return false
+	}
+	for _, g := range p.sprites {
+		if g.match(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceOf names the source of an SVG in messages, which for SVGs extracted
+// from sprite sheets is the sheet and symbol id.
+func (s *SVGWalker) sourceOf(path string) string {
+	if source, ok := s.sprites[path]; ok {
+		return source
+	}
+	return path
+}
+
+// spriteSymbol is a <symbol> of a sprite sheet as a standalone SVG.
+type spriteSymbol struct {
+	id  string
+	svg []byte
+}
+
+// splitSprite returns an SVG for every <symbol> with an id in data. Each is
+// sized by the symbol's width and height, falling back to its view box, and
+// keeps the sheet's top level <defs> and <style> elements for shared
+// gradients and classes.
+func splitSprite(data []byte) ([]spriteSymbol, error) {
+	type span struct{ start, end int }
+	type symbol struct {
+		attrs        []xml.Attr
+		outer, inner span
+	}
+	var (
+		root        xml.StartElement
+		shared      []span
+		symbols     []symbol
+		depth       int
+		inSymbol    = -1
+		symbolDepth int
+		inShared    bool
+	)
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		offset := int(d.InputOffset())
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case depth == 1:
+				root = t.Copy()
+			case inSymbol >= 0:
+			case t.Name.Local == "symbol":
+				symbols = append(symbols, symbol{
+					attrs: t.Copy().Attr,
+					outer: span{offset, -1},
+					inner: span{int(d.InputOffset()), -1},
+				})
+				inSymbol, symbolDepth = len(symbols)-1, depth
+			case depth == 2 && (t.Name.Local == "defs" || t.Name.Local == "style"):
+				shared = append(shared, span{offset, -1})
+				inShared = true
+			}
+		case xml.EndElement:
+			switch {
+			case inSymbol >= 0 && depth == symbolDepth:
+				symbols[inSymbol].inner.end = offset
+				symbols[inSymbol].outer.end = int(d.InputOffset())
+				inSymbol = -1
+			case inShared && depth == 2:
+				shared[len(shared)-1].end = int(d.InputOffset())
+				inShared = false
+			}
+			depth--
+		}
+	}
+
+	// Shared elements without the symbols defined inside them.
+	var defs bytes.Buffer
+	for _, sh := range shared {
+		last := sh.start
+		for _, sym := range symbols {
+			if sym.outer.start >= sh.start && sym.outer.end <= sh.end {
+				defs.Write(data[last:sym.outer.start])
+				last = sym.outer.end
+			}
+		}
+		defs.Write(data[last:sh.end])
+	}
+	namespaces := map[string]string{"xmlns": "http://www.w3.org/2000/svg", "xmlns:xlink": "http://www.w3.org/1999/xlink"}
+	for _, a := range root.Attr {
+		switch {
+		case a.Name.Space == "xmlns":
+			namespaces["xmlns:"+a.Name.Local] = a.Value
+		case a.Name.Space == "" && a.Name.Local == "xmlns":
+			namespaces["xmlns"] = a.Value
+		}
+	}
+
+	names := make([]string, 0, len(namespaces))
+	for name := range namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out []spriteSymbol
+	for _, sym := range symbols {
+		attrs := map[string]string{}
+		for _, a := range sym.attrs {
+			attrs[a.Name.Local] = a.Value
+		}
+		id := attrs["id"]
+		if id == "" {
+			continue
+		}
+		if strings.ContainsAny(id, `/\`) {
+			return nil, errors.Errorf("symbol %q: ids may not contain path separators", id)
+		}
+		width, height := attrs["width"], attrs["height"]
+		if vb := strings.FieldsFunc(attrs["viewBox"], func(r rune) bool { return r == ',' || r == ' ' }); len(vb) == 4 {
+			if width == "" {
+				width = vb[2]
+			}
+			if height == "" {
+				height = vb[3]
+			}
+		}
+		if width == "" || height == "" {
+			return nil, errors.Errorf("symbol %q: needs a viewBox or a width and height", id)
+		}
+		var buf bytes.Buffer
+		buf.WriteString("<svg")
+		for _, name := range names {
+			fmt.Fprintf(&buf, " %s=%q", name, namespaces[name])
+		}
+		fmt.Fprintf(&buf, " width=%q height=%q", width, height)
+		for _, name := range []string{"viewBox", "preserveAspectRatio"} {
+			if v, ok := attrs[name]; ok {
+				fmt.Fprintf(&buf, " %s=%q", name, v)
+			}
+		}
+		buf.WriteString(">")
+		buf.Write(defs.Bytes())
+		buf.Write(data[sym.inner.start:sym.inner.end])
+		buf.WriteString("</svg>")
+		out = append(out, spriteSymbol{id, buf.Bytes()})
+	}
+	return out, nil
+}
+
+// spriteDir returns the folder the symbols of the sprite sheet at path are
+// extracted to.
+func (s *SVGWalker) spriteDir(path string) (string, error) {
+	dir := s.SpriteDir
+	if dir == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			cache = os.TempDir()
+		}
+		dir = filepath.Join(cache, "asset", "sprites")
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])), nil
+}
+
+// addSprite adds an image set for every symbol of the sprite sheet file,
+// relative to dir, to c. Each symbol is extracted to its own SVG, rewritten
+// only when the symbol changes, so that only image sets of changed symbols
+// are rendered again.
+func (s *SVGWalker) addSprite(c *Container, dir, file string, cfg *settings, identity, locale string) error {
+	path := filepath.Join(dir, file)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	symbols, err := splitSprite(data)
+	if err != nil {
+		return errors.Wrapf(err, "%s: failed to split sprite sheet", path)
+	}
+	out, err := s.spriteDir(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(out, 0700); err != nil {
+		return err
+	}
+	if s.sprites == nil {
+		s.sprites = map[string]string{}
+	}
+	for _, sym := range symbols {
+		extracted := filepath.Join(out, sym.id+".svg")
+		if existing, err := ioutil.ReadFile(extracted); err != nil || !bytes.Equal(existing, sym.svg) {
+			if err := ioutil.WriteFile(extracted, sym.svg, 0600); err != nil {
+				return err
+			}
+		}
+		s.sprites[extracted] = path + "#" + sym.id
+		if err := s.addSVGSource(c, extracted, file, sym.id, cfg, identity, locale); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package asset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testSpriteSVG = `<svg xmlns="http://www.w3.org/2000/svg" style="display:none">
+<defs>
+<linearGradient id="shared"/>
+<symbol id="home" viewBox="0 0 24 24"><path d="M0 0h24v24z" fill="url(#shared)"/></symbol>
+</defs>
+<symbol id="gear" viewBox="0 0 32 16" width="16" height="8"><circle r="4"/></symbol>
+<symbol viewBox="0 0 1 1"/>
+</svg>`
+
+func TestSplitSprite(t *testing.T) {
+	symbols, err := splitSprite([]byte(testSpriteSVG))
+	require.NoError(t, err)
+	require.Len(t, symbols, 2)
+	require.Equal(t, "home", symbols[0].id)
+	require.Equal(t, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="24" height="24" viewBox="0 0 24 24"><defs>
+<linearGradient id="shared"/>
+
+</defs><path d="M0 0h24v24z" fill="url(#shared)"/></svg>`, string(symbols[0].svg))
+	require.Equal(t, "gear", symbols[1].id)
+	require.Contains(t, string(symbols[1].svg), `width="16" height="8" viewBox="0 0 32 16">`)
+	require.True(t, strings.HasSuffix(string(symbols[1].svg), `</defs><circle r="4"/></svg>`))
+
+	_, err = splitSprite([]byte(`<svg><symbol id="a"/></svg>`))
+	require.Error(t, err)
+}
+
+func TestSVGWalker_Sprites(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "sprite-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{
+		"asset.yaml":      "scales: [1]\nsprites: ['icons*.svg']\n",
+		"icons.svg":       testSpriteSVG,
+		"nav/icons-2.svg": `<svg><symbol id="back" viewBox="0 0 10 10"/></svg>`,
+	})
+	walk := func() *recordingConverter {
+		conv := &recordingConverter{}
+		catalog := newTestCatalog(t, tmpDir)
+		walker := &SVGWalker{Converter: conv, Catalog: catalog, SpriteDir: filepath.Join(tmpDir, "sprites")}
+		require.NoError(t, walker.Walk(src))
+		require.NoError(t, catalog.Write())
+		return conv
+	}
+	require.Len(t, walk().calls, 3)
+	for _, set := range []string{"home.imageset", "gear.imageset", "nav/back.imageset"} {
+		_, err := os.Stat(filepath.Join(tmpDir, "Test.xcassets", set, "Contents.json"))
+		require.NoError(t, err, set)
+	}
+	image, err := NewImageSet(filepath.Join(tmpDir, "Test.xcassets", "gear.imageset"))
+	require.NoError(t, err)
+	require.Equal(t, "gear-1x.png", image.Images[0].FileName)
+
+	// Changing one symbol only renders its image set again.
+	time.Sleep(10 * time.Millisecond)
+	writeTree(t, src, map[string]string{"icons.svg": strings.Replace(testSpriteSVG, `r="4"`, `r="5"`, 1)})
+	conv := walk()
+	require.Len(t, conv.calls, 1)
+	require.Equal(t, "gear-1x.png", filepath.Base(conv.calls[0].png))
+
+	writeTree(t, src, map[string]string{"home.svg": testSVG})
+	err = (&SVGWalker{Converter: &recordingConverter{}, Catalog: newTestCatalog(t, tmpDir), SpriteDir: filepath.Join(tmpDir, "sprites")}).Walk(src)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "icons.svg#home")
+}
@@ -0,0 +1,300 @@
+package asset
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// LayerPrefix starts the id of the top level groups of an SVG that become
+// image stack layers, such as <g id="layer-front">.
+const LayerPrefix = "layer-"
+
+// svgLayer is a layer of an SVG along with the SVG that draws only it.
+type svgLayer struct {
+	name string
+	data []byte
+}
+
+// splitLayers returns an SVG for each top level layer group of data, from
+// front to back. Each keeps the document's top level <defs> and <style>
+// elements and drops everything else outside of the layer.
+func splitLayers(data []byte) ([]svgLayer, error) {
+	type span struct{ start, end int }
+	var (
+		names         []string
+		layers        []span
+		shared        []span
+		open, closing int
+		depth         int
+		inShared      bool
+	)
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		offset := int(d.InputOffset())
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case depth == 1:
+				open = int(d.InputOffset())
+			case depth != 2:
+			case t.Name.Local == "defs" || t.Name.Local == "style":
+				shared = append(shared, span{offset, -1})
+				inShared = true
+			case t.Name.Local == "g":
+				for _, a := range t.Attr {
+					if a.Name.Local == "id" && strings.HasPrefix(a.Value, LayerPrefix) {
+						names = append(names, strings.TrimPrefix(a.Value, LayerPrefix))
+						layers = append(layers, span{offset, -1})
+					}
+				}
+			}
+		case xml.EndElement:
+			switch {
+			case depth == 1:
+				closing = offset
+			case depth != 2:
+			case inShared:
+				shared[len(shared)-1].end = int(d.InputOffset())
+				inShared = false
+			case len(layers) > 0 && layers[len(layers)-1].end < 0:
+				layers[len(layers)-1].end = int(d.InputOffset())
+			}
+			depth--
+		}
+	}
+	out := make([]svgLayer, len(layers))
+	for i, l := range layers {
+		var buf bytes.Buffer
+		buf.Write(data[:open])
+		for _, sh := range shared {
+			buf.Write(data[sh.start:sh.end])
+		}
+		buf.Write(data[l.start:l.end])
+		buf.Write(data[closing:])
+		// Documents draw later layers on top, stacks list the front first.
+		out[len(layers)-1-i] = svgLayer{name: names[i], data: buf.Bytes()}
+	}
+	return out, nil
+}
+
+// readLayers reads the layers of the SVG at path, requiring the 2 to 5
+// layers tvOS supports.
+func readLayers(path string) ([]svgLayer, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := splitLayers(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: failed to parse svg", path)
+	}
+	if len(layers) < 2 || len(layers) > 5 {
+		return nil, errors.Errorf("%s: image stacks need 2 to 5 <g id=\"%s...\"> layers, found %d", path, LayerPrefix, len(layers))
+	}
+	seen := map[string]bool{}
+	for _, l := range layers {
+		if seen[l.name] {
+			return nil, errors.Errorf("%s: duplicate layer %q", path, l.name)
+		}
+		seen[l.name] = true
+	}
+	return layers, nil
+}
+
+// addImageStack renders every layer of the SVG at path into a stack in dir
+// at the given size in points and scales.
+func (s *SVGWalker) addImageStack(stacks map[string]*ImageStack, dir, name, path string, width, height float32, scales []int) error {
+	layers, err := readLayers(path)
+	if err != nil {
+		return err
+	}
+	cfg, err := s.rootSettings()
+	if err != nil {
+		return err
+	}
+	stack := stacks[name]
+	if stack == nil {
+		if stack, err = NewImageStack(filepath.Join(dir, name+".imagestack")); err != nil {
+			return err
+		}
+		stacks[name] = stack
+	}
+	post := s.postOptions(nil, path)
+	stack.Layers = nil
+	for _, l := range layers {
+		layerName := cfg.sanitized(l.name)
+		folder := layerName + ".imagestacklayer"
+		stack.Layers = append(stack.Layers, ImageStackLayerFile{folder})
+		layer := stack.Contents[folder]
+		if layer == nil {
+			if layer, err = NewImageStackLayer(filepath.Join(stack.Dir, folder)); err != nil {
+				return err
+			}
+			stack.Contents[folder] = layer
+		}
+		content := layer.Content
+		update, err := s.needsUpdate(content.Dir, content.Images, path, len(scales))
+		if err != nil {
+			return err
+		}
+		if !update {
+			continue
+		}
+		data := l.data
+		content.Images = make([]Image, len(scales))
+		for i, scale := range scales {
+			file := fmt.Sprintf("%s-%dx.png", layerName, scale)
+			content.Images[i] = Image{
+				FileName:  file,
+				Idiom:     "tv",
+				Scale:     fmt.Sprintf("%dx", scale),
+				generator: s.svgDataGenerator(content, post, scale, height, width, func() ([]byte, error) { return data, nil }, file),
+			}
+		}
+	}
+	return nil
+}
+
+// AddImageStackSVG splits the SVG at path into the layers of a tvOS image
+// stack named after it. Each top level <g id="layer-name"> group becomes a
+// layer rendered at the size of the SVG.
+func (s *SVGWalker) AddImageStackSVG(path string) error {
+	if !strings.HasSuffix(path, ".svg") {
+		return fmt.Errorf("%s: not an svg file", path)
+	}
+	p, err := readSVG(path)
+	if err != nil {
+		return err
+	}
+	cfg, err := s.rootSettings()
+	if err != nil {
+		return err
+	}
+	name := cfg.sanitized(strings.TrimSuffix(filepath.Base(path), ".svg"))
+	return s.addImageStack(s.Catalog.ImageStacks, s.Catalog.Dir, name, path, p.width, p.height, []int{1, 2})
+}
+
+// BrandAssetsOptions list the SVGs of the tvOS app icons and top shelf
+// images. Empty paths leave existing assets untouched.
+type BrandAssetsOptions struct {
+	// Name of the brand assets. Defaults to "App Icon & Top Shelf Image".
+	Name string
+	// AppIcon is a layered SVG, drawn at 400x240, split into the home
+	// screen and App Store image stacks.
+	AppIcon string
+	// TopShelf and TopShelfWide are drawn at 1920x720 and 2320x720. Top
+	// shelf images cannot be layered, so any layers are rendered together.
+	TopShelf, TopShelfWide string
+}
+
+type brandAssetSpec struct {
+	name, role    string
+	width, height float32
+	scales        []int
+}
+
+var (
+	tvAppIcon           = brandAssetSpec{"App Icon", "primary-app-icon", 400, 240, []int{1, 2}}
+	tvAppStoreIcon      = brandAssetSpec{"App Icon - App Store", "primary-app-icon", 1280, 768, []int{1}}
+	tvTopShelfImage     = brandAssetSpec{"Top Shelf Image", "top-shelf-image", 1920, 720, []int{1, 2}}
+	tvTopShelfImageWide = brandAssetSpec{"Top Shelf Image Wide", "top-shelf-image-wide", 2320, 720, []int{1, 2}}
+)
+
+func (b *BrandAssets) setAsset(spec brandAssetSpec, file string) {
+	asset := BrandAsset{
+		FileName: file,
+		Idiom:    "tv",
+		Role:     spec.role,
+		Size:     fmt.Sprintf("%gx%g", spec.width, spec.height),
+	}
+	for i, a := range b.Assets {
+		if a.FileName == file {
+			b.Assets[i] = asset
+			return
+		}
+	}
+	b.Assets = append(b.Assets, asset)
+}
+
+// AddBrandAssetsSVG renders tvOS brand assets from the SVGs in opts.
+func (s *SVGWalker) AddBrandAssetsSVG(opts BrandAssetsOptions) error {
+	if opts.Name == "" {
+		opts.Name = "App Icon & Top Shelf Image"
+	}
+	for _, path := range []string{opts.AppIcon, opts.TopShelf, opts.TopShelfWide} {
+		if path != "" && !strings.HasSuffix(path, ".svg") {
+			return fmt.Errorf("%s: not an svg file", path)
+		}
+	}
+	b := s.Catalog.BrandAssets[opts.Name]
+	if b == nil {
+		var err error
+		if b, err = NewBrandAssets(filepath.Join(s.Catalog.Dir, opts.Name+".brandassets")); err != nil {
+			return err
+		}
+		s.Catalog.BrandAssets[opts.Name] = b
+	}
+	if opts.AppIcon != "" {
+		for _, spec := range []brandAssetSpec{tvAppIcon, tvAppStoreIcon} {
+			if err := s.addImageStack(b.Stacks, b.Dir, spec.name, opts.AppIcon, spec.width, spec.height, spec.scales); err != nil {
+				return err
+			}
+			b.setAsset(spec, spec.name+".imagestack")
+		}
+	}
+	for _, top := range []struct {
+		spec brandAssetSpec
+		path string
+	}{{tvTopShelfImage, opts.TopShelf}, {tvTopShelfImageWide, opts.TopShelfWide}} {
+		if top.path == "" {
+			continue
+		}
+		if err := s.addTopShelf(b, top.spec, top.path); err != nil {
+			return err
+		}
+		b.setAsset(top.spec, top.spec.name+".imageset")
+	}
+	return nil
+}
+
+func (s *SVGWalker) addTopShelf(b *BrandAssets, spec brandAssetSpec, path string) error {
+	image := b.Images[spec.name]
+	if image == nil {
+		var err error
+		if image, err = NewImageSet(filepath.Join(b.Dir, spec.name+".imageset")); err != nil {
+			return err
+		}
+		b.Images[spec.name] = image
+	}
+	if p, err := s.parseSVG(image.Dir, image.Images, path, len(spec.scales)); err != nil || !p.update {
+		return err
+	}
+	name := strings.ToLower(strings.Replace(spec.name, " ", "-", -1))
+	post := s.postOptions(nil, path)
+	image.Images = make([]Image, len(spec.scales))
+	for i, scale := range spec.scales {
+		file := fmt.Sprintf("%s-%dx.png", name, scale)
+		image.Images[i] = Image{
+			FileName:  file,
+			Idiom:     "tv",
+			Scale:     fmt.Sprintf("%dx", scale),
+			generator: s.pngGenerator(image, post, scale, spec.height, spec.width, path, file),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package asset
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testLayeredSVG = `<svg xmlns="http://www.w3.org/2000/svg" width="40" height="24">
+<defs><linearGradient id="g"/></defs>
+<g id="layer-back"><rect width="40" height="24" fill="#0000ff"/></g>
+<g id="layer-front"><rect x="10" y="6" width="20" height="12" fill="#ff0000"/></g>
+</svg>`
+
+func TestSplitLayers(t *testing.T) {
+	layers, err := splitLayers([]byte(testLayeredSVG))
+	require.NoError(t, err)
+	require.Len(t, layers, 2)
+	require.Equal(t, "front", layers[0].name)
+	require.Contains(t, string(layers[0].data), "#ff0000")
+	require.NotContains(t, string(layers[0].data), "#0000ff")
+	require.Contains(t, string(layers[0].data), "linearGradient")
+	require.Equal(t, "back", layers[1].name)
+	require.NotContains(t, string(layers[1].data), "#ff0000")
+
+	layers, err = splitLayers([]byte(`<svg><style>.a{}</style><rect/><g id="layer-a"/><g><g id="layer-nested"/></g><g id="layer-b"></g></svg>`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "a"}, []string{layers[0].name, layers[1].name})
+	require.Equal(t, `<svg><style>.a{}</style><g id="layer-a"/></svg>`, string(layers[1].data))
+	require.Equal(t, `<svg><style>.a{}</style><g id="layer-b"></g></svg>`, string(layers[0].data))
+}
+
+func TestSVGWalker_AddBrandAssetsSVG(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "stack-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	writeTree(t, tmpDir, map[string]string{
+		"icon.svg":  testLayeredSVG,
+		"shelf.svg": testLayeredSVG,
+		"flat.svg":  testSVG,
+	})
+	catalog := newTestCatalog(t, tmpDir)
+	walker := &SVGWalker{Converter: NativeConverter{}, Catalog: catalog}
+	require.NoError(t, walker.AddBrandAssetsSVG(BrandAssetsOptions{
+		AppIcon:  filepath.Join(tmpDir, "icon.svg"),
+		TopShelf: filepath.Join(tmpDir, "shelf.svg"),
+	}))
+	require.NoError(t, catalog.Write())
+
+	dir := filepath.Join(catalog.Dir, "App Icon & Top Shelf Image.brandassets")
+	var brand BrandAssets
+	data, err := ioutil.ReadFile(filepath.Join(dir, "Contents.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &brand))
+	require.Equal(t, []BrandAsset{
+		{"App Icon.imagestack", "tv", "primary-app-icon", "400x240"},
+		{"App Icon - App Store.imagestack", "tv", "primary-app-icon", "1280x768"},
+		{"Top Shelf Image.imageset", "tv", "top-shelf-image", "1920x720"},
+	}, brand.Assets)
+
+	stack, err := NewImageStack(filepath.Join(dir, "App Icon.imagestack"))
+	require.NoError(t, err)
+	require.Equal(t, []ImageStackLayerFile{{"front.imagestacklayer"}, {"back.imagestacklayer"}}, stack.Layers)
+
+	front, err := NewImageStackLayer(filepath.Join(stack.Dir, "front.imagestacklayer"))
+	require.NoError(t, err)
+	require.Len(t, front.Content.Images, 2)
+	img, err := decodeImage(filepath.Join(front.Content.Dir, front.Content.Images[1].FileName))
+	require.NoError(t, err)
+	require.Equal(t, 800, img.Bounds().Dx())
+	require.Equal(t, 480, img.Bounds().Dy())
+	_, _, _, a := img.At(10, 10).RGBA()
+	require.Equal(t, uint32(0), a, "the back layer is not drawn in front")
+	r, _, _, _ := img.At(400, 240).RGBA()
+	require.Equal(t, uint32(0xffff), r)
+
+	shelf, err := NewImageSet(filepath.Join(dir, "Top Shelf Image.imageset"))
+	require.NoError(t, err)
+	img, err = decodeImage(filepath.Join(shelf.Dir, shelf.Images[0].FileName))
+	require.NoError(t, err)
+	require.Equal(t, 1920, img.Bounds().Dx())
+	_, _, b, _ := img.At(10, 10).RGBA()
+	require.Equal(t, uint32(0xffff), b, "top shelf images draw every layer")
+
+	err = walker.AddBrandAssetsSVG(BrandAssetsOptions{AppIcon: filepath.Join(tmpDir, "flat.svg")})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "found 0")
+}
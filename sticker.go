@@ -0,0 +1,211 @@
+package asset
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// StickerSizes maps the grid sizes of a sticker pack to the size in pixels
+// of its stickers.
+var StickerSizes = map[string]int{
+	"small":   300,
+	"regular": 408,
+	"large":   618,
+}
+
+// StickerPackOptions select the sticker pack that stickers are added to.
+type StickerPackOptions struct {
+	// Name of the pack. Defaults to "Sticker Pack".
+	Name string
+	// GridSize is small, regular or large. Defaults to the size the pack
+	// already has, or regular for new packs.
+	GridSize string
+}
+
+func (s *SVGWalker) stickerPack(opts StickerPackOptions) (*StickerPack, bool, error) {
+	if opts.Name == "" {
+		opts.Name = "Sticker Pack"
+	}
+	if opts.GridSize != "" && StickerSizes[opts.GridSize] == 0 {
+		return nil, false, errors.Errorf("%s: unknown sticker grid size %q", opts.Name, opts.GridSize)
+	}
+	pack := s.Catalog.StickerPacks[opts.Name]
+	if pack == nil {
+		var err error
+		if pack, err = NewStickerPack(filepath.Join(s.Catalog.Dir, opts.Name+".stickerpack")); err != nil {
+			return nil, false, err
+		}
+		s.Catalog.StickerPacks[opts.Name] = pack
+	}
+	resized := opts.GridSize != "" && opts.GridSize != pack.Properties.GridSize
+	if resized {
+		pack.Properties.GridSize = opts.GridSize
+	}
+	return pack, resized, nil
+}
+
+// AddStickerSVG renders the SVG at path into a sticker of the pack, fitting
+// it to the pack's sticker size. New stickers are appended to the pack's
+// ordering and existing ones keep their place. The SVG title, if any,
+// becomes the accessibility label.
+func (s *SVGWalker) AddStickerSVG(path string, opts StickerPackOptions) error {
+	pack, resized, err := s.stickerPack(opts)
+	if err != nil {
+		return err
+	}
+	_, err = s.addSticker(pack, path, filepath.Base(path), resized)
+	return err
+}
+
+// AddStickerDir adds every SVG in dir to a sticker pack in file name order,
+// which also becomes the pack's ordering. Stickers of the pack without an
+// SVG in dir are removed from the ordering.
+func (s *SVGWalker) AddStickerDir(dir string, opts StickerPackOptions) error {
+	pack, resized, err := s.stickerPack(opts)
+	if err != nil {
+		return err
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+	var order []StickerFile
+	for _, info := range infos {
+		if info.IsDir() || filepath.Ext(info.Name()) != ".svg" {
+			continue
+		}
+		folder, err := s.addSticker(pack, filepath.Join(dir, info.Name()), info.Name(), resized)
+		if err != nil {
+			return err
+		}
+		order = append(order, StickerFile{folder})
+	}
+	pack.Stickers = order
+	return nil
+}
+
+// addSticker adds the SVG at path to pack, returning the sticker folder name.
+// Quantize globs are matched against rel.
+func (s *SVGWalker) addSticker(pack *StickerPack, path, rel string, resized bool) (string, error) {
+	if !strings.HasSuffix(path, ".svg") {
+		return "", fmt.Errorf("%s: not an svg file", path)
+	}
+	cfg, err := s.rootSettings()
+	if err != nil {
+		return "", err
+	}
+	name := cfg.sanitized(strings.TrimSuffix(filepath.Base(path), ".svg"))
+	folder := name + ".sticker"
+	if err := s.claimName(s.Catalog.Container, "sticker", filepath.Base(pack.Dir)+"/"+name, path, path); err != nil {
+		return "", err
+	}
+	sticker := pack.Contents[folder]
+	if sticker == nil {
+		if sticker, err = NewSticker(filepath.Join(pack.Dir, folder)); err != nil {
+			return "", err
+		}
+		pack.Contents[folder] = sticker
+	}
+	listed := false
+	for _, f := range pack.Stickers {
+		listed = listed || f.FileName == folder
+	}
+	if !listed {
+		pack.Stickers = append(pack.Stickers, StickerFile{folder})
+	}
+
+	file := name + ".png"
+	images := []Image{{FileName: sticker.Properties.FileName}}
+	if resized || sticker.Properties.FileName != file {
+		images = nil
+	}
+	update, err := s.needsUpdate(sticker.Dir, images, path, 1)
+	if err != nil {
+		return "", err
+	}
+	if !update {
+		s.report.source(path, sticker.Dir, []string{sticker.Properties.FileName}, false)
+		return folder, nil
+	}
+	p, err := readSVG(path)
+	if err != nil {
+		return "", err
+	}
+	size := float32(StickerSizes[pack.Properties.GridSize])
+	height, width := size, size
+	if p.width > p.height {
+		height = size * p.height / p.width
+	} else {
+		width = size * p.width / p.height
+	}
+	sticker.Properties = StickerProperties{FileName: file, AccessibilityLabel: p.title}
+	s.report.source(path, sticker.Dir, []string{file}, true)
+	sticker.generator = s.pngGenerator(&ImageSet{Dir: sticker.Dir}, s.postOptions(cfg, rel), 1, height, width, path, file)
+	return folder, nil
+}
+
+// messagesIconSizes lists the entries of an iMessage app icon set as idiom,
+// platform, scale, width and height in points.
+var messagesIconSizes = []struct {
+	idiom, platform string
+	scale           int
+	width, height   float32
+}{
+	{"iphone", "", 2, 29, 29},
+	{"iphone", "", 3, 29, 29},
+	{"iphone", "", 2, 60, 45},
+	{"iphone", "", 3, 60, 45},
+	{"ipad", "", 2, 29, 29},
+	{"ipad", "", 2, 67, 50},
+	{"ipad", "", 2, 74, 55},
+	{"ios-marketing", "", 1, 1024, 768},
+	{"universal", "ios", 2, 27, 20},
+	{"universal", "ios", 3, 27, 20},
+	{"universal", "ios", 2, 32, 24},
+	{"universal", "ios", 3, 32, 24},
+}
+
+// AddMessagesIconSVG renders the SVG at path to every size of the iMessage
+// app icon. Most sizes are 4:3, so the SVG should be drawn at that ratio.
+func (s *SVGWalker) AddMessagesIconSVG(path string) error {
+	if !strings.HasSuffix(path, ".svg") {
+		return fmt.Errorf("%s: not an svg file", path)
+	}
+	if s.Catalog.MessagesIcon == nil {
+		icon, err := NewImageSet(filepath.Join(s.Catalog.Dir, "iMessage App Icon.stickersiconset"))
+		if err != nil {
+			return err
+		}
+		s.Catalog.MessagesIcon = icon
+	}
+	icon := s.Catalog.MessagesIcon
+	if p, err := s.parseSVG(icon.Dir, icon.Images, path, len(messagesIconSizes)); err != nil || !p.update {
+		return err
+	}
+	cfg, err := s.rootSettings()
+	if err != nil {
+		return err
+	}
+	name := cfg.sanitized(strings.TrimSuffix(filepath.Base(path), ".svg"))
+	post := s.postOptions(nil, path)
+	icon.Images = make([]Image, len(messagesIconSizes))
+	for i, size := range messagesIconSizes {
+		dims := fmt.Sprintf("%gx%g", size.width, size.height)
+		file := fmt.Sprintf("%s-%s-%s@%dx.png", name, size.idiom, dims, size.scale)
+		icon.Images[i] = Image{
+			FileName:  file,
+			Idiom:     size.idiom,
+			Platform:  size.platform,
+			Scale:     fmt.Sprintf("%dx", size.scale),
+			Size:      dims,
+			generator: s.pngGenerator(icon, post, size.scale, size.height, size.width, path, file),
+		}
+	}
+	return nil
+}
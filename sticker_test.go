@@ -0,0 +1,133 @@
+package asset
+
+import (
+	"encoding/json"
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSVGWalker_AddStickerDir(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "sticker-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{
+		"b-cat.svg": `<svg xmlns="http://www.w3.org/2000/svg" width="20" height="10"><title> Cat </title><rect width="20" height="10"/></svg>`,
+		"a-dog.svg": testSVG,
+		"notes.txt": "ignored",
+	})
+
+	catalog := newTestCatalog(t, tmpDir)
+	walker := &SVGWalker{Converter: NativeConverter{}, Catalog: catalog, Quantize: []string{"b-*"}}
+	require.NoError(t, walker.AddStickerDir(src, StickerPackOptions{GridSize: "small"}))
+	require.NoError(t, catalog.Write())
+
+	dir := filepath.Join(catalog.Dir, "Sticker Pack.stickerpack")
+	var pack StickerPack
+	data, err := ioutil.ReadFile(filepath.Join(dir, "Contents.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &pack))
+	require.Equal(t, "small", pack.Properties.GridSize)
+	require.Equal(t, []StickerFile{{"a-dog.sticker"}, {"b-cat.sticker"}}, pack.Stickers)
+
+	var sticker Sticker
+	data, err = ioutil.ReadFile(filepath.Join(dir, "b-cat.sticker", "Contents.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &sticker))
+	require.Equal(t, StickerProperties{FileName: "b-cat.png", AccessibilityLabel: "Cat"}, sticker.Properties)
+
+	for file, size := range map[string][2]int{
+		"b-cat.sticker/b-cat.png": {300, 150},
+		"a-dog.sticker/a-dog.png": {225, 300},
+	} {
+		img, err := decodeImage(filepath.Join(dir, file))
+		require.NoError(t, err)
+		require.Equal(t, size, [2]int{img.Bounds().Dx(), img.Bounds().Dy()}, file)
+		_, paletted := img.(*image.Paletted)
+		require.Equal(t, file == "b-cat.sticker/b-cat.png", paletted, file)
+	}
+
+	// Reloading keeps existing stickers in place, appends new ones and only
+	// re-renders when the grid size changes.
+	catalog = newTestCatalog(t, tmpDir)
+	conv := &recordingConverter{}
+	walker = &SVGWalker{Converter: conv, Catalog: catalog}
+	writeTree(t, tmpDir, map[string]string{"0-owl.svg": testSVG})
+	require.NoError(t, walker.AddStickerSVG(filepath.Join(src, "b-cat.svg"), StickerPackOptions{}))
+	require.NoError(t, walker.AddStickerSVG(filepath.Join(tmpDir, "0-owl.svg"), StickerPackOptions{}))
+	require.NoError(t, catalog.Write())
+	require.Len(t, conv.calls, 1)
+	require.Equal(t, []StickerFile{{"a-dog.sticker"}, {"b-cat.sticker"}, {"0-owl.sticker"}}, catalog.StickerPacks["Sticker Pack"].Stickers)
+
+	require.NoError(t, walker.AddStickerDir(src, StickerPackOptions{GridSize: "large"}))
+	require.NoError(t, catalog.Write())
+	require.Len(t, conv.calls, 3)
+	require.Equal(t, []StickerFile{{"a-dog.sticker"}, {"b-cat.sticker"}}, catalog.StickerPacks["Sticker Pack"].Stickers)
+
+	require.Error(t, walker.AddStickerDir(src, StickerPackOptions{GridSize: "huge"}))
+}
+
+func TestSVGWalker_AddStickerCacheAndNames(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "sticker-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{"a cat.svg": testSVG})
+	cache := &DirCache{Dir: filepath.Join(tmpDir, "cache")}
+	add := func(dir string) (*SVGWalker, *recordingConverter) {
+		conv := &recordingConverter{}
+		catalog := newTestCatalog(t, filepath.Join(tmpDir, dir))
+		walker := &SVGWalker{Converter: conv, Catalog: catalog, Cache: cache, SanitizePaths: true}
+		require.NoError(t, walker.AddStickerDir(src, StickerPackOptions{}))
+		require.NoError(t, catalog.Write())
+		return walker, conv
+	}
+
+	walker, conv := add("first")
+	require.Len(t, conv.calls, 1)
+	report := walker.Report()
+	require.Len(t, report.Sources, 1)
+	require.Equal(t, filepath.Join(src, "a cat.svg"), report.Sources[0].Source)
+	require.Equal(t, OutputGenerated, report.Sources[0].Outputs[0].Status)
+
+	walker, conv = add("second")
+	require.Empty(t, conv.calls)
+	require.Equal(t, OutputCached, walker.Report().Sources[0].Outputs[0].Status)
+
+	writeTree(t, src, map[string]string{"a_cat.svg": testSVG})
+	err = walker.AddStickerDir(src, StickerPackOptions{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "both map to sticker")
+}
+
+func TestSVGWalker_AddMessagesIconSVG(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "sticker-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	icon := filepath.Join(tmpDir, "icon.svg")
+	require.NoError(t, ioutil.WriteFile(icon, []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="40" height="30"></svg>`), 0600))
+	catalog := newTestCatalog(t, tmpDir)
+	walker := &SVGWalker{Converter: NativeConverter{}, Catalog: catalog}
+	require.NoError(t, walker.AddMessagesIconSVG(icon))
+	require.NoError(t, catalog.Write())
+
+	set, err := NewImageSet(filepath.Join(catalog.Dir, "iMessage App Icon.stickersiconset"))
+	require.NoError(t, err)
+	require.Len(t, set.Images, len(messagesIconSizes))
+	last := set.Images[len(set.Images)-1]
+	require.Equal(t, "universal", last.Idiom)
+	require.Equal(t, "ios", last.Platform)
+	require.Equal(t, "32x24", last.Size)
+	img, err := decodeImage(filepath.Join(set.Dir, last.FileName))
+	require.NoError(t, err)
+	require.Equal(t, 96, img.Bounds().Dx())
+	require.Equal(t, 72, img.Bounds().Dy())
+}
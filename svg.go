@@ -15,6 +15,7 @@ import (
 	"bytes"
 
 	"strings"
+	"time"
 
 	"encoding/xml"
 
@@ -28,22 +29,46 @@ type SVGConverter interface {
 
 var ErrNoInkScape = errors.New("inkscape not installed. inkscape (https://www.inkscape.org/) is needed to convert SVG files.")
 
-type InkScapeConverter struct{}
+// InkScapeConverter shells out to inkscape. Major selects the command line
+// flags: 0.x releases use --export-png while 1.0 and later use
+// --export-filename. A zero Major detects the installed version on every
+// call; use NewInkScapeConverter to detect it once, which also works for
+// 0.x releases.
+type InkScapeConverter struct {
+	Major int
+	// detected is set when Major was detected, even if it is zero.
+	detected bool
+}
+
+// known reports whether Major can be used without detecting the version.
+func (i InkScapeConverter) known() bool { return i.detected || i.Major != 0 }
 
-func (InkScapeConverter) Convert(scale int, height, width float32, svgFile, pngFile string) error {
+func (i InkScapeConverter) Convert(scale int, height, width float32, svgFile, pngFile string) error {
 	if _, err := exec.LookPath("inkscape"); err != nil {
 		return ErrNoInkScape
 	}
-	cmd := exec.Command("inkscape",
-		"--without-gui",
-		"--export-height", fmt.Sprintf("%f", int(float32(scale)*height)),
-		"--export-width", fmt.Sprintf("%f", int(float32(scale)*width)),
-		"--export-png", pngFile,
-		svgFile)
-	if out, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("%v: %s", err, string(out))
+	major := i.Major
+	if !i.known() {
+		var err error
+		if major, err = InkScapeVersion(); err != nil {
+			return err
+		}
 	}
-	return nil
+	h, w := pixelSize(scale, height, width)
+	if major < 1 {
+		return runConverter("inkscape",
+			"--without-gui",
+			"--export-height", strconv.Itoa(h),
+			"--export-width", strconv.Itoa(w),
+			"--export-png", pngFile,
+			svgFile)
+	}
+	return runConverter("inkscape",
+		"--export-type=png",
+		"--export-height="+strconv.Itoa(h),
+		"--export-width="+strconv.Itoa(w),
+		"--export-filename="+pngFile,
+		svgFile)
 }
 
 type PhantomJSConverter struct {
@@ -111,13 +136,13 @@ func (p *PhantomJSConverter) Convert(scale int, height, width float32, svgFile,
 	}
 	f := filepath.Join(p.dir, "out.html")
 	var buf bytes.Buffer
-	h, w := int(float32(scale)*height), int(float32(scale)*width)
+	h, w := pixelSize(scale, height, width)
 	d := map[string]interface{}{"File": abs, "Height": h, "Width": w}
 	if err = svgHTMLTemplate.Execute(&buf, d); err != nil {
-		return errors.Wrapf(err, "%s: failed to generate html")
+		return errors.Wrapf(err, "%s: failed to generate html", svgFile)
 	}
 	if err = ioutil.WriteFile(f, buf.Bytes(), 0600); err != nil {
-		return errors.Wrapf(err, "%s: failed to write html")
+		return errors.Wrapf(err, "%s: failed to write html", svgFile)
 	}
 	call := fmt.Sprintf("function (done) {renderSVG(%q, %d, %d, done);}", "file://"+f, h, w)
 	if err = p.p.Run(call, &result); err != nil {
@@ -151,26 +176,91 @@ type SVGWalker struct {
 	Catalog       *Catalog
 	SanitizePaths bool
 	ForceUpdate   bool
+	ResourceTags  []TagRule
+	AllowUpscale  bool
+	// MirrorRTL generates a right-to-left variant of every SVG without one
+	// by mirroring it horizontally. Configs may override it per folder.
+	MirrorRTL bool
+	// Data lists globs, relative to the walked directory, of files copied
+	// into data sets instead of being rendered.
+	Data []string
+	// Symbols lists globs, relative to the walked directory, of SVG glyphs
+	// turned into custom symbol sets. A glyph may be qualified with its
+	// weight, as in bell~black.svg.
+	Symbols []string
+	// Sprites lists globs, relative to the walked directory, of SVG sprite
+	// sheets whose <symbol> elements each become an image set named after
+	// their id.
+	Sprites []string
+	// Recolor adds recolored copies of the SVGs matching its rules.
+	Recolor []RecolorRule
+	// SpriteDir holds the SVGs extracted from sprite sheets, which are only
+	// rewritten when their symbol changes. Defaults to a folder in the user
+	// cache directory.
+	SpriteDir string
+	// Optimize losslessly recompresses every generated PNG. See Savings.
+	Optimize bool
+	// Quantize lists globs, relative to the walked directory, of sources
+	// whose PNGs are reduced to an 8-bit palette. Quantized PNGs whose error
+	// exceeds QuantizeMaxError keep their original colors.
+	Quantize         []string
+	QuantizeMaxError float64
+	// Cache, if set, is consulted before converting an SVG and stores every
+	// new render.
+	Cache RenderCache
+
+	// Sanitizer, if set, replaces the default space replacing sanitizer and
+	// implies SanitizePaths.
+	Sanitizer Sanitizer
+	// ImageSetName, FileName and AppIconFileName are text/template naming
+	// templates executed with a NameData. Empty values use the defaults.
+	ImageSetName    string
+	FileName        string
+	AppIconFileName string
+
+	settings map[string]*settings
+	rasters  map[string]*rasterSet
+	names    map[string]nameClaim
+	variants map[*ImageSet]map[string]variantClaim
+	mirrors  []mirrorRequest
+
+	dataClaims map[*DataSet]map[string]string
+	symbols    map[*SymbolSet]map[string]string
+	sprites    map[string]string
+	savings    savingsLog
+	report     buildLog
 }
 
-func (s *SVGWalker) sanitized(path string) string {
-	if !s.SanitizePaths {
-		return path
-	}
-	return strings.Replace(path, " ", "_", -1)
-}
-
+// Walk adds every source below dir to the catalog. It is Reset, AddPath for
+// every file and Finish.
 func (s *SVGWalker) Walk(dir string) error {
-	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	s.Reset()
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 		return s.AddPath(dir, path, info)
 	})
+	if err != nil {
+		return err
+	}
+	return s.Finish()
+}
+
+// Reset forgets the sources added since the last Walk or Reset: their name
+// claims, configs, pending mirrors and symbols and the build report. Callers
+// of AddPath must call it before adding a new set of paths.
+func (s *SVGWalker) Reset() {
+	s.settings, s.rasters, s.names, s.variants, s.mirrors = nil, nil, nil, nil, nil
+	s.dataClaims, s.symbols, s.sprites = nil, nil, nil
+	s.report.reset()
 }
 
+// AddPath adds the source at path, found while walking dir, to the catalog.
+// Any source Walk handles is added, not just SVGs. Call Reset before the
+// first path and Finish once every path is added.
 func (s *SVGWalker) AddPath(dir, path string, info os.FileInfo) error {
-	if info.IsDir() || filepath.Ext(info.Name()) != ".svg" {
+	if info.IsDir() {
 		return nil
 	}
 	f, err := filepath.Rel(dir, path)
@@ -181,71 +271,269 @@ func (s *SVGWalker) AddPath(dir, path string, info os.FileInfo) error {
 }
 
 func (s *SVGWalker) add(dir, file string) error {
-	path, _ := filepath.Dir(file), filepath.Base(file)
+	cfg, err := s.settingsFor(dir, filepath.Dir(file))
+	if err != nil {
+		return err
+	}
+	if !cfg.includes(file) {
+		return nil
+	}
+	data := cfg.isData(file)
+	if !data && filepath.Ext(file) != ".svg" && !isRaster(file) {
+		return nil
+	}
 	holder := s.Catalog.Container
-	for path != "." && path != "" {
-		var (
-			group string
-			err   error
-		)
-		path, group = filepath.Dir(path), filepath.Base(path)
-		g, err := holder.AddGroup(s.sanitized(group))
+	path, identity, locale := ".", dir, ""
+	for _, group := range strings.Split(filepath.ToSlash(filepath.Dir(file)), "/") {
+		if group == "." || group == "" {
+			continue
+		}
+		parentCfg, err := s.settingsFor(dir, path)
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(path, group)
+		if l, ok := parentCfg.localeOf(group); ok {
+			if locale != "" {
+				return errors.Errorf("%s: nested locale folders", filepath.Join(dir, path))
+			}
+			locale = l
+			continue
+		}
+		identity = filepath.Join(identity, group)
+		groupCfg, err := s.settingsFor(dir, path)
+		if err != nil {
+			return err
+		}
+		name := groupCfg.sanitized(group)
+		if err := holder.checkGroupCase(name, filepath.Join(dir, path)); err != nil {
+			return err
+		}
+		g, err := holder.AddGroup(name)
 		if err != nil {
 			return err
 		}
+		if groupCfg.namespace != nil {
+			g.Properties.ProvidesNamespace = *groupCfg.namespace
+		}
+		g.namespace = holder.namespace
+		if g.Properties.ProvidesNamespace {
+			g.namespace += name + "/"
+		}
 		holder = g.Container
 	}
-	return s.addSVG(holder, filepath.Join(dir, file))
+	switch {
+	case data:
+		return s.addData(holder, dir, file, cfg, identity, locale)
+	case cfg.isSymbol(file):
+		return s.addSymbol(holder, dir, file, cfg, identity, locale)
+	case cfg.isSprite(file):
+		return s.addSprite(holder, dir, file, cfg, identity, locale)
+	case isRaster(file):
+		return s.addRaster(holder, dir, file, cfg, identity, locale)
+	}
+	return s.addSVG(holder, dir, file, cfg, identity, locale)
 }
 
-func (s *SVGWalker) addSVG(c *Container, path string) error {
+// addSVG adds the SVG file, relative to dir, to c. identity is the folder the
+// file would be in without locale folders and locale is its locale, if any.
+func (s *SVGWalker) addSVG(c *Container, dir, file string, cfg *settings, identity, locale string) error {
+	path := filepath.Join(dir, file)
 	if !strings.HasSuffix(path, ".svg") {
 		return fmt.Errorf("%s: not an svg file", path)
 	}
+	return s.addSVGSource(c, path, file, strings.TrimSuffix(filepath.Base(path), ".svg"), cfg, identity, locale)
+}
 
-	name := filepath.Base(path)
-	target := s.sanitized(name[0 : len(name)-4])
+// addSVGSource adds the SVG at path, named name, to c. file is the source
+// the config rules are matched against, which differs from path for SVGs
+// extracted from sprite sheets.
+func (s *SVGWalker) addSVGSource(c *Container, path, file, name string, cfg *settings, identity, locale string) error {
+	base, v, err := parseVariant(name, cfg.idiom)
+	if err != nil {
+		return err
+	}
+	v = v.localized(locale)
+	if err := s.addSVGImageSet(c, base, v, path, file, cfg, identity, svgTransform{}); err != nil {
+		return err
+	}
+	for _, r := range cfg.recolorsFor(file) {
+		if err := s.addSVGImageSet(c, base+"-"+r.suffix, v, path, file, cfg, identity, svgTransform{colors: r.colors}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	image := c.Images[target]
-	if image == nil {
+// addSVGImageSet renders the SVG at path, transformed by t, into the v
+// entries of the image set named after base.
+func (s *SVGWalker) addSVGImageSet(c *Container, base string, v variant, path, file string, cfg *settings, identity string, t svgTransform) error {
+	target, err := cfg.imageSetNameFor(base)
+	if err != nil {
+		return err
+	}
+	image, err := s.imageSet(c, target, filepath.Join(identity, base+".svg"), s.sourceOf(path))
+	if err != nil {
+		return err
+	}
+	image.Properties.OnDemandResourceTags = s.resourceTags(cfg, file)
+	if cfg.mirrorRTL && v.direction == "" {
+		s.mirrors = append(s.mirrors, mirrorRequest{image, target, v, path, file, cfg, t})
+	}
+	return s.addSVGVariant(image, target, v, path, file, cfg, t)
+}
+
+// addSVGVariant renders the SVG at path, transformed by t, into the v
+// entries of image.
+func (s *SVGWalker) addSVGVariant(image *ImageSet, target string, v variant, path, file string, cfg *settings, t svgTransform) error {
+	scales := v.scales(cfg)
+	files := make([]string, len(scales))
+	for i, scale := range scales {
 		var err error
-		img := filepath.Join(c.Dir, target+".imageset")
-		if image, err = NewImageSet(img); err != nil {
+		if files[i], err = cfg.fileNameFor(target, scale, v); err != nil {
 			return err
 		}
-		c.Images[target] = image
 	}
-	p, err := s.parseSVG(image, path, 3)
-	if err != nil || !p.update {
+	source := s.sourceOf(path)
+	if t.mirror {
+		source += " (mirrored)"
+	}
+	if err := s.claimVariant(image, v, source, files); err != nil {
 		return err
 	}
-
-	image.Images = make([]Image, 3)
-	for i := 1; i < 4; i++ {
-		file := fmt.Sprintf("%s-%dx.png", target, i)
-		image.Images[i-1] = Image{
-			Scale:     fmt.Sprintf("%dx", i),
-			FileName:  file,
-			Idiom:     "universal",
-			generator: s.pngGenerator(image, i, p.height, p.width, path, file),
+	p, err := s.parseSVG(image.Dir, variantImages(image.Images, v), path, len(scales))
+	if err != nil {
+		return err
+	}
+	s.report.source(source, image.Dir, files, p.update)
+	if !p.update {
+		return nil
+	}
+	if size := cfg.sizeFor(file); size != nil {
+		if size.Height > 0 {
+			p.height = size.Height
+		}
+		if size.Width > 0 {
+			p.width = size.Width
+		}
+	}
+	slice := cfg.sliceFor(file)
+	if slice == nil {
+		slice = p.slice
+	}
+	if slice != nil {
+		if err := slice.validate(s.sourceOf(path)); err != nil {
+			return err
 		}
 	}
+	if t.mirror {
+		slice = slice.mirrored()
+	}
+
+	post := s.postOptions(cfg, file)
+	images := make([]Image, len(scales))
+	for i, scale := range scales {
+		images[i] = slice.apply(v.apply(Image{
+			Scale:     fmt.Sprintf("%dx", scale),
+			FileName:  files[i],
+			generator: s.transformGenerator(image, post, scale, p.height, p.width, path, t, files[i]),
+		}), scale, p.width, p.height)
+	}
+	image.Images = replaceVariant(image.Images, v, images)
 	return nil
 }
 
-func (s *SVGWalker) pngGenerator(i *ImageSet, scale int, height, width float32, svg, out string) func() error {
+func (s *SVGWalker) pngGenerator(i *ImageSet, post postOptions, scale int, height, width float32, svg, out string) func() error {
 	return func() error {
 		file := filepath.Join(i.Dir, out)
-		Log("Generating", file)
-		return s.Converter.Convert(scale, height, width, svg, file)
+		var key string
+		if s.Cache != nil {
+			var err error
+			if key, err = renderKey(s.Converter, svg, scale, height, width, post); err != nil {
+				return err
+			}
+		}
+		return s.cachedRender(key, file, func() error {
+			Log("Generating", file)
+			start := time.Now()
+			if err := s.Converter.Convert(scale, height, width, svg, file); err != nil {
+				return err
+			}
+			s.report.rendered(file, OutputGenerated, time.Since(start))
+			return s.postProcess(i, file, post)
+		})
 	}
 }
 
-func (s *SVGWalker) parseSVG(i *ImageSet, path string, expected int) (parsedSVG, error) {
-	update, err := s.needsUpdate(i, path, expected)
+// svgDataGenerator renders SVG data built when the generator runs, such as
+// a transformed copy of a source, through a temporary file.
+func (s *SVGWalker) svgDataGenerator(i *ImageSet, post postOptions, scale int, height, width float32, svg func() ([]byte, error), out string) func() error {
+	return func() error {
+		data, err := svg()
+		if err != nil {
+			return err
+		}
+		tmp, err := ioutil.TempFile("", "render*.svg")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		if _, err := tmp.Write(data); err != nil {
+			tmp.Close()
+			return err
+		}
+		if err := tmp.Close(); err != nil {
+			return err
+		}
+		return s.pngGenerator(i, post, scale, height, width, tmp.Name(), out)()
+	}
+}
+
+// svgTransform rewrites an SVG before it is rendered.
+type svgTransform struct {
+	mirror bool
+	colors ColorMap
+}
+
+func (t svgTransform) apply(path string, data []byte) ([]byte, error) {
+	var err error
+	if len(t.colors) > 0 {
+		if data, err = recolorSVG(data, t.colors); err != nil {
+			return nil, errors.Wrapf(err, "%s: failed to recolor", path)
+		}
+	}
+	if t.mirror {
+		if data, err = mirrorSVG(data); err != nil {
+			return nil, errors.Wrapf(err, "%s: failed to mirror", path)
+		}
+	}
+	return data, nil
+}
+
+// transformGenerator renders svg transformed by t.
+func (s *SVGWalker) transformGenerator(i *ImageSet, post postOptions, scale int, height, width float32, svg string, t svgTransform, out string) func() error {
+	if !t.mirror && len(t.colors) == 0 {
+		return s.pngGenerator(i, post, scale, height, width, svg, out)
+	}
+	return s.svgDataGenerator(i, post, scale, height, width, func() ([]byte, error) {
+		data, err := ioutil.ReadFile(svg)
+		if err != nil {
+			return nil, err
+		}
+		return t.apply(svg, data)
+	}, out)
+}
+
+func (s *SVGWalker) parseSVG(dir string, images []Image, path string, expected int) (parsedSVG, error) {
+	update, err := s.needsUpdate(dir, images, path, expected)
 	if err != nil || !update {
 		return parsedSVG{}, err
 	}
+	return readSVG(path)
+}
+
+// readSVG reads the size and slicing metadata of the SVG at path.
+func readSVG(path string) (parsedSVG, error) {
 	data, err := ioutil.ReadFile(path)
 	if err != nil {
 		return parsedSVG{}, err
@@ -258,22 +546,26 @@ func (s *SVGWalker) parseSVG(i *ImageSet, path string, expected int) (parsedSVG,
 	if err != nil {
 		return parsedSVG{}, errors.Wrapf(err, "%s: failed to parse dim", path)
 	}
-	return parsedSVG{update, h, w}, nil
+	slice, err := svgSlice(v.Attrs)
+	if err != nil {
+		return parsedSVG{}, errors.Wrapf(err, "%s: failed to parse slicing", path)
+	}
+	return parsedSVG{true, h, w, slice, strings.TrimSpace(v.Title)}, nil
 }
 
-func (s *SVGWalker) needsUpdate(i *ImageSet, svg string, expected int) (bool, error) {
+func (s *SVGWalker) needsUpdate(dir string, images []Image, svg string, expected int) (bool, error) {
 	if s.ForceUpdate {
 		return true, nil
 	}
-	if len(i.Images) != expected {
+	if len(images) != expected {
 		return true, nil
 	}
 	svgStat, err := os.Stat(svg)
 	if err != nil {
 		return false, err
 	}
-	for _, image := range i.Images {
-		stat, err := os.Stat(filepath.Join(i.Dir, image.FileName))
+	for _, image := range images {
+		stat, err := os.Stat(filepath.Join(dir, image.FileName))
 		if err != nil {
 			return true, nil
 		}
@@ -288,11 +580,15 @@ type parsedSVG struct {
 	update bool
 	height float32
 	width  float32
+	slice  *Slice
+	title  string
 }
 
 type svg struct {
-	Height string `xml:"height,attr"`
-	Width  string `xml:"width,attr"`
+	Height string     `xml:"height,attr"`
+	Width  string     `xml:"width,attr"`
+	Attrs  []xml.Attr `xml:",any,attr"`
+	Title  string     `xml:"title"`
 }
 
 func (s svg) dim() (float32, float32, error) {
@@ -0,0 +1,357 @@
+package asset
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+)
+
+// Symbol weights accepted as ~ultralight, ~regular and ~black qualifiers of
+// symbol sources.
+var SymbolWeights = []string{"Ultralight", "Regular", "Black"}
+
+// The geometry of the small scale rows of the SF Symbols template, in
+// template units.
+const (
+	symbolTemplateWidth  = 3300
+	symbolTemplateHeight = 2200
+	symbolBaseline       = 696.0
+	symbolCapHeight      = 70.459
+	symbolGuideLeft      = 263.0
+	symbolGuideRight     = 3036.0
+)
+
+// symbolColumns are the horizontal centers of each weight's glyph.
+var symbolColumns = map[string]float64{
+	"Ultralight": 559,
+	"Regular":    1449,
+	"Black":      2339,
+}
+
+// symbolOvershoot is how far, as a fraction of the cap height, a glyph may
+// extend above the cap line or below the baseline.
+const symbolOvershoot = 0.5
+
+// isSymbol reports whether file is a custom symbol source.
+func (p *settings) isSymbol(file string) bool {
+	if filepath.Ext(file) != ".svg" {
+		return false
+	}
+	for _, g := range p.symbols {
+		if g.match(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// symbolWeight splits a symbol source name into the symbol name and its
+// weight. Names without a weight qualifier are Regular.
+func symbolWeight(name string) (string, string) {
+	if idx := strings.LastIndex(name, VariantSeparator); idx >= 0 {
+		for _, w := range SymbolWeights {
+			if strings.EqualFold(name[idx+1:], w) {
+				return name[:idx], w
+			}
+		}
+	}
+	return name, "Regular"
+}
+
+// addSymbol adds an SVG glyph, relative to dir, as one weight of a symbol
+// set in c. The template is built once the walk has seen every weight.
+func (s *SVGWalker) addSymbol(c *Container, dir, file string, cfg *settings, identity, locale string) error {
+	path := filepath.Join(dir, file)
+	if locale != "" {
+		return errors.Errorf("%s: symbol sets cannot be localized", path)
+	}
+	name, weight := symbolWeight(strings.TrimSuffix(filepath.Base(file), ".svg"))
+	target := cfg.sanitized(name)
+	if err := s.claimName(c, "symbol set", target, filepath.Join(identity, name+"@symbol"), path); err != nil {
+		return err
+	}
+	set := c.SymbolSets[target]
+	if set == nil {
+		var err error
+		if set, err = NewSymbolSet(filepath.Join(c.Dir, target+".symbolset")); err != nil {
+			return err
+		}
+		c.SymbolSets[target] = set
+	}
+	set.Properties.OnDemandResourceTags = s.resourceTags(cfg, file)
+	if s.symbols == nil {
+		s.symbols = map[*SymbolSet]map[string]string{}
+	}
+	weights := s.symbols[set]
+	if weights == nil {
+		weights = map[string]string{}
+		s.symbols[set] = weights
+	}
+	if existing, ok := weights[weight]; ok {
+		return errors.Errorf("%s and %s: both provide the %s weight of %s", existing, path, weight, target)
+	}
+	weights[weight] = path
+	return nil
+}
+
+// finishSymbols validates the weights of every symbol set seen during the
+// walk and schedules the templates that are out of date.
+func (s *SVGWalker) finishSymbols() error {
+	for set, weights := range s.symbols {
+		name := strings.TrimSuffix(filepath.Base(set.Dir), ".symbolset")
+		if _, ok := weights["Regular"]; !ok || (len(weights) != 1 && len(weights) != len(SymbolWeights)) {
+			return errors.Errorf("%s: a symbol needs a regular weight, or ultralight, regular and black weights", name)
+		}
+		file := name + ".svg"
+		update, err := s.symbolNeedsUpdate(set, file, weights)
+		if err != nil || !update {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		data, err := SymbolTemplate(weights)
+		if err != nil {
+			return err
+		}
+		out := filepath.Join(set.Dir, file)
+		set.Symbols = []Symbol{{
+			FileName: file,
+			Idiom:    "universal",
+			generator: func() error {
+				Log("Generating", out)
+				return ioutil.WriteFile(out, data, 0644)
+			},
+		}}
+	}
+	return nil
+}
+
+func (s *SVGWalker) symbolNeedsUpdate(set *SymbolSet, file string, weights map[string]string) (bool, error) {
+	if s.ForceUpdate || len(set.Symbols) != 1 || set.Symbols[0].FileName != file {
+		return true, nil
+	}
+	out, err := os.Stat(filepath.Join(set.Dir, file))
+	if err != nil {
+		return true, nil
+	}
+	for _, path := range weights {
+		src, err := os.Stat(path)
+		if err != nil {
+			return false, err
+		}
+		if out.ModTime().Before(src.ModTime()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// glyph is one weight of a symbol, measured in template units.
+type glyph struct {
+	body []byte
+	// scale and baseline map glyph units to template units.
+	scale, baseline float64
+	// left, right, top and bottom bound the ink relative to the glyph
+	// origin and the baseline, with y growing down.
+	left, right, top, bottom float64
+}
+
+// readGlyph reads a glyph SVG. The view box height is its cap height and
+// the bottom of the view box its baseline, unless the root element sets
+// data-cap-height or data-baseline in view box units.
+func readGlyph(path string) (*glyph, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	root, open, end, err := svgRoot(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: failed to parse svg", path)
+	}
+	var vb [4]float64
+	attr := func(name string) string {
+		for _, a := range root.Attr {
+			if a.Name.Local == name {
+				return a.Value
+			}
+		}
+		return ""
+	}
+	if v := attr("viewBox"); v != "" {
+		f := strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+		if len(f) != 4 {
+			return nil, errors.Errorf("%s: invalid viewBox %q", path, v)
+		}
+		for i := range vb {
+			if vb[i], err = strconv.ParseFloat(f[i], 64); err != nil {
+				return nil, errors.Wrapf(err, "%s: invalid viewBox", path)
+			}
+		}
+	} else {
+		h, w, err := svg{Height: attr("height"), Width: attr("width")}.dim()
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: failed to parse dim", path)
+		}
+		vb[2], vb[3] = float64(w), float64(h)
+	}
+	capHeight, baseline := vb[3], vb[1]+vb[3]
+	for name, v := range map[string]*float64{"data-cap-height": &capHeight, "data-baseline": &baseline} {
+		if s := attr(name); s != "" {
+			if *v, err = strconv.ParseFloat(s, 64); err != nil {
+				return nil, errors.Wrapf(err, "%s: invalid %s", path, name)
+			}
+		}
+	}
+	if capHeight <= 0 {
+		return nil, errors.Errorf("%s: cap height must be positive", path)
+	}
+
+	minX, minY, maxX, maxY, ok, err := inkBounds(data, vb)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: failed to measure glyph", path)
+	}
+	if !ok {
+		return nil, errors.Errorf("%s: glyph is empty", path)
+	}
+	g := &glyph{body: data[open:end], scale: symbolCapHeight / capHeight, baseline: baseline}
+	g.left, g.right = minX*g.scale, maxX*g.scale
+	g.top, g.bottom = (minY-baseline)*g.scale, (maxY-baseline)*g.scale
+	overshoot := symbolCapHeight * symbolOvershoot
+	if g.top < -symbolCapHeight-overshoot {
+		return nil, errors.Errorf("%s: glyph extends %.1f units above the cap line", path, -g.top-symbolCapHeight)
+	}
+	if g.bottom > overshoot {
+		return nil, errors.Errorf("%s: glyph extends %.1f units below the baseline", path, g.bottom)
+	}
+	return g, nil
+}
+
+// svgRoot returns the root element of an SVG along with the offsets of the
+// XML inside it.
+func svgRoot(data []byte) (xml.StartElement, int, int, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return xml.StartElement{}, 0, 0, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		open := int(d.InputOffset())
+		end := bytes.LastIndex(data, []byte("</"))
+		if end < open {
+			// A self closing root.
+			end = open
+		}
+		return start, open, end, nil
+	}
+}
+
+// inkBounds renders an SVG with a margin around its view box and returns the
+// bounds of its non-transparent pixels in view box units.
+func inkBounds(data []byte, vb [4]float64) (minX, minY, maxX, maxY float64, ok bool, err error) {
+	icon, err := oksvg.ReadIconStream(bytes.NewReader(data), oksvg.WarnErrorMode)
+	if err != nil {
+		return 0, 0, 0, 0, false, err
+	}
+	res := 1024 / math.Max(vb[2], vb[3])
+	w, h := int(math.Ceil(2*vb[2]*res)), int(math.Ceil(2*vb[3]*res))
+	ox, oy := vb[2]*res/2, vb[3]*res/2
+	icon.Transform = rasterx.Identity.Translate(ox, oy).Scale(res, res).Translate(-vb[0], -vb[1])
+	img := image.NewAlpha(image.Rect(0, 0, w, h))
+	icon.Draw(rasterx.NewDasher(w, h, rasterx.NewScannerGV(w, h, img, img.Bounds())), 1)
+	x0, y0, x1, y1 := w, h, -1, -1
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if img.AlphaAt(x, y).A == 0 {
+				continue
+			}
+			if x < x0 {
+				x0 = x
+			}
+			if x > x1 {
+				x1 = x
+			}
+			if y < y0 {
+				y0 = y
+			}
+			y1 = y
+		}
+	}
+	if x1 < 0 {
+		return 0, 0, 0, 0, false, nil
+	}
+	toX := func(px int) float64 { return vb[0] + (float64(px)-ox)/res }
+	toY := func(px int) float64 { return vb[1] + (float64(px)-oy)/res }
+	return toX(x0), toY(y0), toX(x1 + 1), toY(y1 + 1), true, nil
+}
+
+// SymbolTemplate builds an SF Symbols template from glyph SVGs keyed by
+// weight. It needs a Regular glyph, or Ultralight, Regular and Black glyphs.
+// Each glyph is scaled so its cap height matches the template's, placed on
+// the small scale baseline and given margins at its ink bounds.
+func SymbolTemplate(weights map[string]string) ([]byte, error) {
+	if _, ok := weights["Regular"]; !ok {
+		return nil, errors.New("symbol template needs a Regular glyph")
+	}
+	var (
+		guides, symbols bytes.Buffer
+		names           []string
+	)
+	for _, w := range SymbolWeights {
+		if _, ok := weights[w]; ok {
+			names = append(names, w)
+		}
+	}
+	line := `  <line id="%s" style="fill:none;stroke:#27AAE1;opacity:1;stroke-width:0.5;" x1="%s" x2="%s" y1="%s" y2="%s"/>` + "\n"
+	fmt.Fprintf(&guides, line, "Baseline-S", num(symbolGuideLeft), num(symbolGuideRight), num(symbolBaseline), num(symbolBaseline))
+	capline := symbolBaseline - symbolCapHeight
+	fmt.Fprintf(&guides, line, "Capline-S", num(symbolGuideLeft), num(symbolGuideRight), num(capline), num(capline))
+	for _, w := range names {
+		g, err := readGlyph(weights[w])
+		if err != nil {
+			return nil, err
+		}
+		tx := symbolColumns[w] - (g.left+g.right)/2
+		ty := symbolBaseline - g.baseline*g.scale
+		top, bottom := num(capline-symbolCapHeight*symbolOvershoot), num(symbolBaseline+symbolCapHeight*symbolOvershoot)
+		fmt.Fprintf(&guides, line, "left-margin-"+w+"-S", num(tx+g.left), num(tx+g.left), top, bottom)
+		fmt.Fprintf(&guides, line, "right-margin-"+w+"-S", num(tx+g.right), num(tx+g.right), top, bottom)
+		fmt.Fprintf(&symbols, "  <g id=\"%s-S\" transform=\"matrix(%s 0 0 %s %s %s)\">\n%s\n  </g>\n",
+			w, num(g.scale), num(g.scale), num(tx), num(ty), bytes.TrimSpace(g.body))
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE svg PUBLIC "-//W3C//DTD SVG 1.1//EN" "http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd">
+<svg version="1.1" xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="%d" height="%d">
+ <!--template writer version: "asset"-->
+ <g id="Notes">
+  <rect height="%d" id="artboard" style="fill:white;opacity:1" width="%d" x="0" y="0"/>
+ </g>
+ <g id="Guides">
+%s </g>
+ <g id="Symbols">
+%s </g>
+</svg>
+`, symbolTemplateWidth, symbolTemplateHeight, symbolTemplateHeight, symbolTemplateWidth, guides.Bytes(), symbols.Bytes())
+	return buf.Bytes(), nil
+}
+
+// num formats template coordinates with at most three decimals.
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
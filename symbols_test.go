@@ -0,0 +1,99 @@
+package asset
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// glyphSVG draws a box from the baseline to the cap line, inset by 2 units
+// horizontally.
+const glyphSVG = `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 20 10"><rect x="2" y="0" width="16" height="10"/></svg>`
+
+func TestSymbolWeight(t *testing.T) {
+	for name, expected := range map[string][2]string{
+		"bell":            {"bell", "Regular"},
+		"bell~black":      {"bell", "Black"},
+		"bell~UltraLight": {"bell", "Ultralight"},
+		"bell~ipad":       {"bell~ipad", "Regular"},
+	} {
+		n, w := symbolWeight(name)
+		require.Equal(t, expected, [2]string{n, w}, name)
+	}
+}
+
+func TestSVGWalker_Symbols(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "symbols-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{
+		"asset.yaml":          "symbols: ['*.svg']\n",
+		"bell~ultralight.svg": glyphSVG,
+		"bell~regular.svg":    glyphSVG,
+		"bell~black.svg":      glyphSVG,
+		"custom.box.fill.svg": glyphSVG,
+	})
+
+	catalog := newTestCatalog(t, tmpDir)
+	walker := &SVGWalker{Converter: &recordingConverter{}, Catalog: catalog}
+	require.NoError(t, walker.Walk(src))
+	require.NoError(t, catalog.Write())
+	require.Empty(t, catalog.Images)
+
+	data, err := ioutil.ReadFile(filepath.Join(catalog.Dir, "bell.symbolset", "Contents.json"))
+	require.NoError(t, err)
+	var contents SymbolSet
+	require.NoError(t, json.Unmarshal(data, &contents))
+	require.Equal(t, []Symbol{{FileName: "bell.svg", Idiom: "universal"}}, contents.Symbols)
+
+	template, err := ioutil.ReadFile(filepath.Join(catalog.Dir, "bell.symbolset", "bell.svg"))
+	require.NoError(t, err)
+	for _, id := range []string{
+		"Baseline-S", "Capline-S",
+		"left-margin-Ultralight-S", "right-margin-Regular-S", "left-margin-Black-S",
+		"Ultralight-S", "Regular-S", "Black-S",
+	} {
+		require.Contains(t, string(template), `id="`+id+`"`)
+	}
+	// The 20 unit wide view box is scaled to the cap height and the glyph is
+	// centered on the Regular column.
+	require.Contains(t, string(template), `<g id="Regular-S" transform="matrix(7.046 0 0 7.046 1378.541 625.541)">`)
+
+	single, err := ioutil.ReadFile(filepath.Join(catalog.Dir, "custom.box.fill.symbolset", "custom.box.fill.svg"))
+	require.NoError(t, err)
+	require.Contains(t, string(single), `id="Regular-S"`)
+	require.False(t, strings.Contains(string(single), `id="Black-S"`))
+}
+
+func TestSVGWalker_SymbolsInvalid(t *testing.T) {
+	for name, files := range map[string]map[string]string{
+		"missing weight": {
+			"bell~regular.svg": glyphSVG,
+			"bell~black.svg":   glyphSVG,
+		},
+		"too tall": {
+			"bell.svg": `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 20 10" data-cap-height="4"><rect width="20" height="10"/></svg>`,
+		},
+		"empty": {
+			"bell.svg": `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 20 10"></svg>`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tmpDir, err := ioutil.TempDir("", "symbols-test")
+			require.NoError(t, err)
+			defer os.RemoveAll(tmpDir)
+
+			src := filepath.Join(tmpDir, "src")
+			writeTree(t, src, files)
+			walker := &SVGWalker{Converter: &recordingConverter{}, Catalog: newTestCatalog(t, tmpDir), Symbols: []string{"*.svg"}}
+			require.Error(t, walker.Walk(src))
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package asset
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MaxResourceTagBytes is the largest total size Apple accepts for the assets
+// sharing a single on-demand resource tag.
+const MaxResourceTagBytes = 512 << 20
+
+// TagRule assigns on-demand resource tags to every source whose path,
+// relative to the walked directory, matches Pattern. A pattern without any
+// glob characters also matches a folder of that name anywhere in the path.
+type TagRule struct {
+	Pattern string
+	Tags    []string
+}
+
+func (r TagRule) match(file string) bool {
+	if (globRule{".", r.Pattern}).match(file) {
+		return true
+	}
+	if strings.ContainsAny(r.Pattern, `*?[\`) {
+		return false
+	}
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(file)), "/") {
+		if dir == r.Pattern {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SVGWalker) resourceTags(cfg *settings, file string) []string {
+	tags := append([]string(nil), cfg.tags...)
+	for _, r := range s.ResourceTags {
+		if r.match(file) {
+			tags = appendUnique(tags, r.Tags...)
+		}
+	}
+	return tags
+}
+
+type TagUsage struct {
+	Tag   string
+	Bytes int64
+	// Sets counts the tagged sets of every kind, including data and
+	// symbol sets.
+	Sets int
+}
+
+func (t TagUsage) OverLimit() bool {
+	return t.Bytes > MaxResourceTagBytes
+}
+
+// ResourceTagUsage totals the bytes written for every on-demand resource tag
+// in the container, counting image, data and symbol sets. Tags on a group
+// apply to everything inside it.
+func (c *Container) ResourceTagUsage() ([]TagUsage, error) {
+	usage := map[string]*TagUsage{}
+	if err := c.tagUsage(nil, usage); err != nil {
+		return nil, err
+	}
+	list := make([]TagUsage, 0, len(usage))
+	for _, u := range usage {
+		list = append(list, *u)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Tag < list[j].Tag })
+	return list, nil
+}
+
+func (c *Container) tagUsage(inherited []string, usage map[string]*TagUsage) error {
+	for _, g := range c.Groups {
+		tags := appendUnique(append([]string(nil), inherited...), g.Properties.OnDemandResourceTags...)
+		if err := g.tagUsage(tags, usage); err != nil {
+			return err
+		}
+	}
+	type taggedSet struct {
+		dir   string
+		tags  []string
+		files []string
+	}
+	var sets []taggedSet
+	for _, i := range c.Images {
+		set := taggedSet{i.Dir, i.Properties.OnDemandResourceTags, nil}
+		for _, image := range i.Images {
+			set.files = append(set.files, image.FileName)
+		}
+		sets = append(sets, set)
+	}
+	for _, d := range c.DataSets {
+		set := taggedSet{d.Dir, d.Properties.OnDemandResourceTags, nil}
+		for _, data := range d.Data {
+			set.files = append(set.files, data.FileName)
+		}
+		sets = append(sets, set)
+	}
+	for _, s := range c.SymbolSets {
+		set := taggedSet{s.Dir, s.Properties.OnDemandResourceTags, nil}
+		for _, symbol := range s.Symbols {
+			set.files = append(set.files, symbol.FileName)
+		}
+		sets = append(sets, set)
+	}
+	for _, set := range sets {
+		tags := appendUnique(append([]string(nil), inherited...), set.tags...)
+		if len(tags) == 0 {
+			continue
+		}
+		size, err := filesBytes(set.dir, set.files)
+		if err != nil {
+			return err
+		}
+		for _, t := range tags {
+			u := usage[t]
+			if u == nil {
+				u = &TagUsage{Tag: t}
+				usage[t] = u
+			}
+			u.Bytes += size
+			u.Sets++
+		}
+	}
+	return nil
+}
+
+// filesBytes totals the size of the named files in dir that exist.
+func filesBytes(dir string, files []string) (int64, error) {
+	var total int64
+	for _, f := range files {
+		if f == "" {
+			continue
+		}
+		stat, err := os.Stat(filepath.Join(dir, f))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return 0, err
+		}
+		total += stat.Size()
+	}
+	return total, nil
+}
@@ -0,0 +1,68 @@
+package asset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagRule_Match(t *testing.T) {
+	for _, c := range []struct {
+		pattern, file string
+		match         bool
+	}{
+		{"levels", "levels/one.svg", true},
+		{"levels", "world/levels/one.svg", true},
+		{"levels", "levels.svg", false},
+		{"world/*.svg", "world/one.svg", true},
+		{"world/*.svg", "world/levels/one.svg", false},
+		{"hero-*", "a/hero-1.svg", true},
+	} {
+		require.Equal(t, c.match, TagRule{Pattern: c.pattern}.match(c.file), "%+v", c)
+	}
+}
+
+func TestSVGWalker_ResourceTags(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tags-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{
+		"plain.svg":         testSVG,
+		"level1/boss.svg":   testSVG,
+		"level1/tree.svg":   testSVG,
+		"level2/asset.yaml": "on-demand-resource-tags: [level2]\n",
+		"level2/boss.svg":   testSVG,
+		"level2/map.json":   "{}",
+	})
+	catalog := newTestCatalog(t, tmpDir)
+	walker := &SVGWalker{
+		Converter: &recordingConverter{},
+		Catalog:   catalog,
+		Data:      []string{"*.json"},
+		ResourceTags: []TagRule{
+			{Pattern: "level1", Tags: []string{"level1"}},
+			{Pattern: "boss.svg", Tags: []string{"bosses"}},
+		},
+	}
+	require.NoError(t, walker.Walk(src))
+	require.NoError(t, catalog.Write())
+
+	require.Empty(t, catalog.Images["plain"].Properties.OnDemandResourceTags)
+	level1 := catalog.Groups["level1"]
+	require.Equal(t, []string{"level1", "bosses"}, level1.Images["boss"].Properties.OnDemandResourceTags)
+	require.Equal(t, []string{"level1"}, level1.Images["tree"].Properties.OnDemandResourceTags)
+	require.Equal(t, []string{"level2", "bosses"}, catalog.Groups["level2"].Images["boss"].Properties.OnDemandResourceTags)
+
+	usage, err := catalog.ResourceTagUsage()
+	require.NoError(t, err)
+	require.Equal(t, []TagUsage{
+		{Tag: "bosses", Bytes: 18, Sets: 2},
+		{Tag: "level1", Bytes: 18, Sets: 2},
+		{Tag: "level2", Bytes: 11, Sets: 2},
+	}, usage)
+}
@@ -0,0 +1,238 @@
+package asset
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// VariantSeparator separates a source name from its variant qualifiers, as in
+// hero~ipad.svg or hero~ipad~compact.svg. Every variant of a name adds its
+// own entries to the same image set.
+const VariantSeparator = "~"
+
+// variant holds the device and size class traits of one source. The zero
+// value, apart from the idiom, is the source without qualifiers.
+type variant struct {
+	// name is the qualifier text, such as "ipad~compact".
+	name               string
+	idiom              string
+	subtype            string
+	screenWidth        string
+	widthClass         string
+	heightClass        string
+	memory             string
+	graphicsFeatureSet string
+	locale             string
+	direction          string
+}
+
+// idiomScales are the scales rendered for a source qualified with an idiom.
+var idiomScales = map[string][]int{
+	"iphone": {2, 3},
+	"ipad":   {1, 2},
+	"watch":  {2},
+	"tv":     {1, 2},
+	"mac":    {1, 2},
+	"car":    {2, 3},
+}
+
+// watchSubtypes maps Apple Watch case sizes to their screen width traits.
+var watchSubtypes = map[string]string{
+	"38mm": "<=145",
+	"40mm": ">161",
+	"42mm": ">145",
+	"44mm": ">183",
+}
+
+var (
+	memoryQualifier   = regexp.MustCompile(`^([1-9][0-9]*)gb$`)
+	graphicsQualifier = regexp.MustCompile(`^(metal[1-9]v[1-9]|apple[1-9][0-9]*)$`)
+)
+
+// parseVariant splits a source base name into its name and variant. idiom is
+// used when the name has no idiom qualifier.
+func parseVariant(base, idiom string) (string, variant, error) {
+	parts := strings.Split(base, VariantSeparator)
+	v := variant{name: strings.Join(parts[1:], VariantSeparator), idiom: idiom}
+	set := func(field *string, value, q string) error {
+		if *field != "" {
+			return errors.Errorf("%s: conflicting variant qualifier %q", base, q)
+		}
+		*field = value
+		return nil
+	}
+	var idiomSet bool
+	for _, q := range parts[1:] {
+		var err error
+		lq := strings.ToLower(q)
+		switch {
+		case idiomScales[lq] != nil:
+			if idiomSet {
+				return "", variant{}, errors.Errorf("%s: conflicting variant qualifier %q", base, q)
+			}
+			v.idiom, idiomSet = lq, true
+		case strings.HasPrefix(lq, "watch-") && watchSubtypes[lq[len("watch-"):]] != "":
+			if idiomSet && v.idiom != "watch" {
+				return "", variant{}, errors.Errorf("%s: conflicting variant qualifier %q", base, q)
+			}
+			v.idiom, idiomSet = "watch", true
+			if err = set(&v.subtype, lq[len("watch-"):], q); err == nil {
+				v.screenWidth = watchSubtypes[v.subtype]
+			}
+		case lq == "rtl":
+			err = set(&v.direction, RightToLeft, q)
+		case lq == "ltr":
+			err = set(&v.direction, LeftToRight, q)
+		case lq == "compact" || lq == "regular":
+			err = set(&v.widthClass, lq, q)
+		case lq == "compact-height" || lq == "regular-height":
+			err = set(&v.heightClass, strings.TrimSuffix(lq, "-height"), q)
+		case memoryQualifier.MatchString(lq):
+			err = set(&v.memory, lq[:len(lq)-2]+"GB", q)
+		case graphicsQualifier.MatchString(lq):
+			err = set(&v.graphicsFeatureSet, lq, q)
+		default:
+			return "", variant{}, errors.Errorf("%s: unknown variant qualifier %q", base, q)
+		}
+		if err != nil {
+			return "", variant{}, err
+		}
+	}
+	return parts[0], v, nil
+}
+
+// scales returns the scales to render the variant at.
+func (v variant) scales(cfg *settings) []int {
+	if v.name != "" {
+		if scales := idiomScales[v.idiom]; scales != nil {
+			return scales
+		}
+	}
+	return cfg.scales
+}
+
+// apply copies the variant traits onto an image entry.
+func (v variant) apply(i Image) Image {
+	i.Idiom = v.idiom
+	i.Subtype = v.subtype
+	i.ScreenWidth = v.screenWidth
+	i.WidthClass = v.widthClass
+	i.HeightClass = v.heightClass
+	i.Memory = v.memory
+	i.GraphicsFeatureSet = v.graphicsFeatureSet
+	i.Locale = v.locale
+	i.LanguageDirection = v.direction
+	return i
+}
+
+// localized returns the variant for a source in a locale folder. The locale
+// leads the qualifier text so that file names stay unique.
+func (v variant) localized(locale string) variant {
+	if locale == "" {
+		return v
+	}
+	v.locale = locale
+	v.name = strings.Trim(locale+VariantSeparator+v.name, VariantSeparator)
+	return v
+}
+
+// slot identifies the entries of an image set that belong to one variant.
+func (v variant) slot() string {
+	return slotOf(v.apply(Image{}))
+}
+
+// slotOf treats left-to-right entries as unqualified. Which of them are
+// marked left-to-right is decided once the walk is done.
+func slotOf(i Image) string {
+	direction := i.LanguageDirection
+	if direction == LeftToRight {
+		direction = ""
+	}
+	return strings.Join([]string{i.Idiom, i.Subtype, i.ScreenWidth, i.WidthClass,
+		i.HeightClass, i.Memory, i.GraphicsFeatureSet, i.Locale, direction}, "|")
+}
+
+// variantImages returns the entries of images that belong to v.
+func variantImages(images []Image, v variant) []Image {
+	var matched []Image
+	slot := v.slot()
+	for _, i := range images {
+		if slotOf(i) == slot {
+			matched = append(matched, i)
+		}
+	}
+	return matched
+}
+
+// replaceVariant replaces the entries of images that belong to v with
+// updated, keeping the position of the first one.
+func replaceVariant(images []Image, v variant, updated []Image) []Image {
+	slot := v.slot()
+	result := make([]Image, 0, len(images)+len(updated))
+	inserted := false
+	for _, i := range images {
+		if slotOf(i) != slot {
+			result = append(result, i)
+		} else if !inserted {
+			result = append(result, updated...)
+			inserted = true
+		}
+	}
+	if !inserted {
+		result = append(result, updated...)
+	}
+	return result
+}
+
+// variantClaim records the source of one variant of an image set.
+type variantClaim struct {
+	source    string
+	direction string
+}
+
+// claimVariant records that source provides the v entries of image, failing
+// if another source already does or a file name is shared with a different
+// variant.
+func (s *SVGWalker) claimVariant(image *ImageSet, v variant, source string, files []string) error {
+	if s.variants == nil {
+		s.variants = map[*ImageSet]map[string]variantClaim{}
+	}
+	claims := s.variants[image]
+	if claims == nil {
+		claims = map[string]variantClaim{}
+		s.variants[image] = claims
+	}
+	slot := v.slot()
+	if existing, ok := claims[slot]; ok && existing.source != source {
+		return errors.Errorf("%s and %s: both provide the same variant of %s", existing.source, source, filepath.Base(image.Dir))
+	}
+	claims[slot] = variantClaim{source, v.direction}
+	for _, i := range image.Images {
+		if slotOf(i) == slot {
+			continue
+		}
+		for _, f := range files {
+			if strings.EqualFold(i.FileName, f) {
+				return errors.Errorf("%s: file name %s is used by another variant of %s", source, f, filepath.Base(image.Dir))
+			}
+		}
+	}
+	return nil
+}
+
+// pruneVariants drops entries of image sets produced during the walk whose
+// variant no longer has a source.
+func (s *SVGWalker) pruneVariants() {
+	for image, claims := range s.variants {
+		kept := image.Images[:0]
+		for _, i := range image.Images {
+			if _, ok := claims[slotOf(i)]; ok {
+				kept = append(kept, i)
+			}
+		}
+		image.Images = kept
+	}
+}
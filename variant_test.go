@@ -0,0 +1,91 @@
+package asset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVariant(t *testing.T) {
+	for base, expected := range map[string]variant{
+		"hero":         {idiom: "universal"},
+		"hero~ipad":    {name: "ipad", idiom: "ipad"},
+		"hero~compact": {name: "compact", idiom: "universal", widthClass: "compact"},
+		"hero~iPhone~regular-height": {
+			name: "iPhone~regular-height", idiom: "iphone", heightClass: "regular",
+		},
+		"hero~watch-44mm":       {name: "watch-44mm", idiom: "watch", subtype: "44mm", screenWidth: ">183"},
+		"hero~2gb~metal2v2":     {name: "2gb~metal2v2", idiom: "universal", memory: "2GB", graphicsFeatureSet: "metal2v2"},
+		"hero~ipad~compact":     {name: "ipad~compact", idiom: "ipad", widthClass: "compact"},
+		"hero~watch~watch-38mm": {name: "watch~watch-38mm", idiom: "watch", subtype: "38mm", screenWidth: "<=145"},
+	} {
+		name, v, err := parseVariant(base, "universal")
+		require.NoError(t, err, base)
+		require.Equal(t, "hero", name, base)
+		require.Equal(t, expected, v, base)
+	}
+	for _, base := range []string{"hero~phone", "hero~ipad~iphone", "hero~compact~regular", "hero~ipad~watch-40mm"} {
+		_, _, err := parseVariant(base, "universal")
+		require.Error(t, err, base)
+	}
+}
+
+func TestSVGWalker_Variants(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "variant-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	writeTree(t, src, map[string]string{
+		"hero.svg":            testSVG,
+		"hero~ipad.svg":       `<svg xmlns="http://www.w3.org/2000/svg" width="60" height="80"></svg>`,
+		"hero~compact.svg":    testSVG,
+		"hero~watch-44mm.svg": testSVG,
+	})
+
+	catalog := newTestCatalog(t, tmpDir)
+	conv := &recordingConverter{}
+	walker := &SVGWalker{Converter: conv, Catalog: catalog}
+	require.NoError(t, walker.Walk(src))
+	require.NoError(t, catalog.Write())
+
+	hero := catalog.Images["hero"]
+	require.NotNil(t, hero)
+	require.Len(t, catalog.Images, 1)
+	entries := map[string]Image{}
+	for _, i := range hero.Images {
+		i.generator = nil
+		entries[i.FileName] = i
+	}
+	require.Equal(t, map[string]Image{
+		"hero-1x.png":            {FileName: "hero-1x.png", Idiom: "universal", Scale: "1x"},
+		"hero-2x.png":            {FileName: "hero-2x.png", Idiom: "universal", Scale: "2x"},
+		"hero-3x.png":            {FileName: "hero-3x.png", Idiom: "universal", Scale: "3x"},
+		"hero~compact-1x.png":    {FileName: "hero~compact-1x.png", Idiom: "universal", Scale: "1x", WidthClass: "compact"},
+		"hero~compact-2x.png":    {FileName: "hero~compact-2x.png", Idiom: "universal", Scale: "2x", WidthClass: "compact"},
+		"hero~compact-3x.png":    {FileName: "hero~compact-3x.png", Idiom: "universal", Scale: "3x", WidthClass: "compact"},
+		"hero~ipad-1x.png":       {FileName: "hero~ipad-1x.png", Idiom: "ipad", Scale: "1x"},
+		"hero~ipad-2x.png":       {FileName: "hero~ipad-2x.png", Idiom: "ipad", Scale: "2x"},
+		"hero~watch-44mm-2x.png": {FileName: "hero~watch-44mm-2x.png", Idiom: "watch", Scale: "2x", Subtype: "44mm", ScreenWidth: ">183"},
+	}, entries)
+	for _, call := range conv.calls {
+		if filepath.Base(call.png) == "hero~ipad-2x.png" {
+			require.Equal(t, []float32{60, 80}, []float32{call.width, call.height})
+		}
+	}
+
+	// Removing a variant drops its entries on the next walk.
+	require.NoError(t, os.Remove(filepath.Join(src, "hero~compact.svg")))
+	catalog = newTestCatalog(t, tmpDir)
+	walker = &SVGWalker{Converter: &recordingConverter{}, Catalog: catalog}
+	require.NoError(t, walker.Walk(src))
+	require.Len(t, catalog.Images["hero"].Images, 6)
+
+	// A custom file name template that ignores the variant is rejected.
+	catalog = newTestCatalog(t, tmpDir)
+	walker = &SVGWalker{Converter: &recordingConverter{}, Catalog: catalog, ForceUpdate: true, FileName: "{{.Name}}-{{.Scale}}.png"}
+	require.Error(t, walker.Walk(src))
+}